@@ -0,0 +1,91 @@
+package http
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+)
+
+const defaultSessionLimit = 64
+
+// WithSessionAffinity pins the requests sharing a session key, see ContextWithSessionKey, to a dedicated connection
+// pool limited to a single connection per host, so they keep reusing the same upstream connection.
+// This bypasses connection-level load balancing for these requests: all the requests of a session reach the backend
+// that accepted its connection, whereas requests without a session key keep using the shared pool.
+// Up to 64 session pools are kept, see WithSessionAffinityLimit, the least recently used one being evicted and its idle
+// connections closed when a new session exceeds the limit. It is a no-op when the base round tripper is not an *http.Transport.
+func WithSessionAffinity() TransportOption {
+	return func(t *HeadersTransport) {
+		t.sessionAffinity = true
+	}
+}
+
+// WithSessionAffinityLimit sets the maximum number of session pools kept by WithSessionAffinity, 64 by default.
+func WithSessionAffinityLimit(n int) TransportOption {
+	return func(t *HeadersTransport) {
+		t.sessionLimit = n
+	}
+}
+
+type sessionTransports struct {
+	mux        sync.Mutex
+	transports map[string]*list.Element
+	// lru holds the sessions from the most to the least recently used one
+	lru *list.List
+}
+
+type sessionTransport struct {
+	key       string
+	transport *http.Transport
+}
+
+func (s *sessionTransports) get(base *http.Transport, key string, limit int) *http.Transport {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.transports == nil {
+		s.transports = make(map[string]*list.Element)
+		s.lru = list.New()
+	}
+	if elem, ok := s.transports[key]; ok {
+		s.lru.MoveToFront(elem)
+		return elem.Value.(*sessionTransport).transport
+	}
+	if limit <= 0 {
+		limit = defaultSessionLimit
+	}
+	for s.lru.Len() >= limit {
+		oldest := s.lru.Back()
+		session := s.lru.Remove(oldest).(*sessionTransport)
+		delete(s.transports, session.key)
+		// connections still in use are closed by the idle timeout once released
+		session.transport.CloseIdleConnections()
+	}
+
+	transport := base.Clone()
+	transport.MaxConnsPerHost = 1
+	transport.MaxIdleConnsPerHost = 1
+	if transport.IdleConnTimeout == 0 {
+		transport.IdleConnTimeout = http.DefaultTransport.(*http.Transport).IdleConnTimeout
+	}
+	s.transports[key] = s.lru.PushFront(&sessionTransport{
+		key:       key,
+		transport: transport,
+	})
+	return transport
+}
+
+func (t *HeadersTransport) sessionRoundTripper(req *http.Request) http.RoundTripper {
+	if !t.sessionAffinity {
+		return t.roundTripper
+	}
+	base, ok := t.roundTripper.(*http.Transport)
+	if !ok {
+		return t.roundTripper
+	}
+	key, ok := sessionKeyFromContext(req.Context())
+	if !ok {
+		return t.roundTripper
+	}
+	return t.sessionTransports.get(base, key, t.sessionLimit)
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSessionAffinity(t *testing.T) {
+	var newConns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: NewHeadersTransport(&http.Transport{}, WithSessionAffinity()),
+	}
+	doConcurrently := func(ctx context.Context, n int) {
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+				assert.NoError(t, err)
+				res, err := client.Do(req)
+				assert.NoError(t, err)
+				_, _ = io.Copy(io.Discard, res.Body)
+				_ = res.Body.Close()
+			}()
+		}
+		wg.Wait()
+	}
+
+	doConcurrently(ContextWithSessionKey(context.Background(), "session-a"), 5)
+	assert.Equal(t, int32(1), newConns.Load())
+
+	doConcurrently(ContextWithSessionKey(context.Background(), "session-a"), 5)
+	assert.Equal(t, int32(1), newConns.Load())
+
+	doConcurrently(ContextWithSessionKey(context.Background(), "session-b"), 5)
+	assert.Equal(t, int32(2), newConns.Load())
+}
+
+func TestWithSessionAffinityLimit(t *testing.T) {
+	var newConns, closedConns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			newConns.Add(1)
+		case http.StateClosed:
+			closedConns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	transport := NewHeadersTransport(&http.Transport{}, WithSessionAffinity(), WithSessionAffinityLimit(2)).(*HeadersTransport)
+	client := &http.Client{Transport: transport}
+	doRequest := func(key string) {
+		req, err := http.NewRequestWithContext(ContextWithSessionKey(context.Background(), key), http.MethodGet,
+			server.URL, nil)
+		assert.NoError(t, err)
+		res, err := client.Do(req)
+		assert.NoError(t, err)
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+	}
+
+	doRequest("session-a")
+	doRequest("session-b")
+	doRequest("session-a")
+	assert.Equal(t, int32(2), newConns.Load())
+	assert.Equal(t, int32(0), closedConns.Load())
+
+	// session-b is the least recently used one
+	doRequest("session-c")
+	assert.Equal(t, int32(3), newConns.Load())
+	assert.Eventually(t, func() bool {
+		return closedConns.Load() == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 2, transport.sessionTransports.lru.Len())
+
+	doRequest("session-a")
+	assert.Equal(t, int32(3), newConns.Load())
+	doRequest("session-b")
+	assert.Equal(t, int32(4), newConns.Load())
+}
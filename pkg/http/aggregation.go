@@ -0,0 +1,89 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrorSummary summarizes the errors of the same type returned for requests to the same host within a window.
+type ErrorSummary struct {
+	Host string
+	// Type is the Go type of the errors returned by the base round tripper, e.g. *net.OpError.
+	Type      string
+	Count     int
+	First     time.Time
+	Last      time.Time
+	LastError error
+}
+
+// WithErrorAggregation groups request errors by host and type, and calls report once per window when a group reaches
+// threshold occurrences, instead of surfacing every single error, to reduce alert noise.
+func WithErrorAggregation(window time.Duration, threshold int, report func(summary ErrorSummary)) TransportOption {
+	return func(t *HeadersTransport) {
+		t.errorAggregator = &errorAggregator{
+			window:    window,
+			threshold: threshold,
+			report:    report,
+		}
+	}
+}
+
+type errorAggregator struct {
+	window    time.Duration
+	threshold int
+	report    func(ErrorSummary)
+
+	mux    sync.Mutex
+	groups map[string]*errorGroup
+}
+
+type errorGroup struct {
+	summary  ErrorSummary
+	reported bool
+}
+
+func (a *errorAggregator) clone() *errorAggregator {
+	return &errorAggregator{
+		window:    a.window,
+		threshold: a.threshold,
+		report:    a.report,
+	}
+}
+
+func (a *errorAggregator) record(req *http.Request, err error) {
+	now := time.Now()
+	errType := fmt.Sprintf("%T", err)
+	key := req.URL.Host + "/" + errType
+
+	a.mux.Lock()
+	if a.groups == nil {
+		a.groups = make(map[string]*errorGroup)
+	}
+	group, ok := a.groups[key]
+	if !ok || now.Sub(group.summary.First) > a.window {
+		group = &errorGroup{
+			summary: ErrorSummary{
+				Host:  req.URL.Host,
+				Type:  errType,
+				First: now,
+			},
+		}
+		a.groups[key] = group
+	}
+	group.summary.Count++
+	group.summary.Last = now
+	group.summary.LastError = err
+
+	shouldReport := !group.reported && group.summary.Count >= a.threshold
+	if shouldReport {
+		group.reported = true
+	}
+	summary := group.summary
+	a.mux.Unlock()
+
+	if shouldReport {
+		a.report(summary)
+	}
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithErrorAggregation(t *testing.T) {
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	})
+	var summaries []ErrorSummary
+	transport := NewHeadersTransport(base, WithErrorAggregation(100*time.Millisecond, 3, func(summary ErrorSummary) {
+		summaries = append(summaries, summary)
+	}))
+	send := func(host string, n int) {
+		for i := 0; i < n; i++ {
+			req, err := http.NewRequest(http.MethodGet, "http://"+host, nil)
+			assert.NoError(t, err)
+			_, err = transport.RoundTrip(req)
+			assert.Error(t, err)
+		}
+	}
+
+	send("a.example.com", 5)
+	send("b.example.com", 2)
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, "a.example.com", summaries[0].Host)
+	assert.Equal(t, "*net.OpError", summaries[0].Type)
+	assert.Equal(t, 3, summaries[0].Count)
+	assert.Error(t, summaries[0].LastError)
+
+	time.Sleep(150 * time.Millisecond)
+	send("a.example.com", 3)
+	assert.Len(t, summaries, 2)
+	assert.Equal(t, 3, summaries[1].Count)
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	baggageHeader = "baggage"
+	// Limits defined by the W3C baggage specification.
+	maxBaggageMembers = 64
+	maxBaggageBytes   = 8192
+)
+
+// WithBaggagePropagation serializes the baggage set via ContextWithBaggage into the W3C baggage header of the requests.
+// Members exceeding the limits of the specification, 64 members and 8192 bytes, are dropped.
+func WithBaggagePropagation() TransportOption {
+	return func(t *HeadersTransport) {
+		t.baggagePropagation = true
+	}
+}
+
+type baggageContextKey struct{}
+
+// ContextWithBaggage returns a copy of ctx carrying the given baggage members, merged on top of the ones already present.
+func ContextWithBaggage(ctx context.Context, members map[string]string) context.Context {
+	baggage := maps.Clone(BaggageFromContext(ctx))
+	if baggage == nil {
+		baggage = make(map[string]string, len(members))
+	}
+	maps.Copy(baggage, members)
+	return context.WithValue(ctx, baggageContextKey{}, baggage)
+}
+
+// BaggageFromContext returns the baggage members set via ContextWithBaggage.
+func BaggageFromContext(ctx context.Context) map[string]string {
+	baggage, _ := ctx.Value(baggageContextKey{}).(map[string]string)
+	return baggage
+}
+
+// ParseBaggage parses the value of a W3C baggage header into its members, ignoring member properties.
+func ParseBaggage(header string) (map[string]string, error) {
+	baggage := make(map[string]string)
+	for _, member := range strings.Split(header, ",") {
+		member, _, _ = strings.Cut(member, ";")
+		key, value, ok := strings.Cut(member, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid baggage member '%s'", member)
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("error decoding baggage member '%s': %v", member, err)
+		}
+		baggage[strings.TrimSpace(key)] = value
+	}
+	return baggage, nil
+}
+
+func (t *HeadersTransport) propagateBaggage(req *http.Request) {
+	if !t.baggagePropagation {
+		return
+	}
+	if baggage := serializeBaggage(BaggageFromContext(req.Context())); baggage != "" {
+		req.Header.Set(baggageHeader, baggage)
+	}
+}
+
+func serializeBaggage(baggage map[string]string) string {
+	var (
+		members []string
+		size    int
+	)
+	for _, key := range slices.Sorted(maps.Keys(baggage)) {
+		if ValidateHeaderName(key) != nil {
+			continue
+		}
+		member := key + "=" + url.PathEscape(baggage[key])
+		memberSize := len(member)
+		if len(members) > 0 {
+			memberSize++
+		}
+		if len(members) == maxBaggageMembers || size+memberSize > maxBaggageBytes {
+			break
+		}
+		members = append(members, member)
+		size += memberSize
+	}
+	return strings.Join(members, ",")
+}
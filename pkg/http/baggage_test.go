@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithBaggagePropagation(t *testing.T) {
+	var gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("baggage")
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: NewHeadersTransport(nil, WithBaggagePropagation()),
+	}
+	ctx := ContextWithBaggage(context.Background(), map[string]string{
+		"tenant": "acme",
+	})
+	ctx = ContextWithBaggage(ctx, map[string]string{
+		"request": "reconcile mariadb/default,1",
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	assert.NoError(t, err)
+	res, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+
+	assert.Equal(t, "request=reconcile%20mariadb%2Fdefault%2C1,tenant=acme", gotHeader)
+	baggage, err := ParseBaggage(gotHeader)
+	assert.NoError(t, err)
+	assert.Equal(t, BaggageFromContext(ctx), baggage)
+}
+
+func TestSerializeBaggageLimits(t *testing.T) {
+	baggage := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		baggage[fmt.Sprintf("key%03d", i)] = "value"
+	}
+	assert.Len(t, strings.Split(serializeBaggage(baggage), ","), maxBaggageMembers)
+
+	baggage = map[string]string{
+		"a": strings.Repeat("x", 5000),
+		"b": strings.Repeat("x", 5000),
+	}
+	assert.Equal(t, "a="+strings.Repeat("x", 5000), serializeBaggage(baggage))
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// NewTransportWithTLS creates a HeadersTransport wrapping a clone of http.DefaultTransport which uses tlsConfig, e.g. to
+// trust an internal CA or present client certificates. The config is cloned, and TLS 1.2 is enforced as minimum version
+// when none is set. A nil config uses the defaults with a TLS 1.2 minimum version.
+func NewTransportWithTLS(tlsConfig *tls.Config, opts ...TransportOption) http.RoundTripper {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	if tlsConfig.MinVersion == 0 {
+		tlsConfig.MinVersion = tls.VersionTLS12
+	}
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = tlsConfig
+	return NewHeadersTransport(base, opts...)
+}
+
+// WithNetworkPreference constrains the network used to dial connections, e.g. "tcp4" to prefer IPv4 in dual-stack clusters.
+// It is a no-op when the base round tripper is not an *http.Transport.
+func WithNetworkPreference(network string) TransportOption {
+	return withBaseTransport(func(transport *http.Transport) {
+		dial := transport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{}).DialContext
+		}
+		transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		}
+	})
+}
+
+// WithTLSSessionCache sets an LRU TLS session cache with the given capacity on the base transport, enabling session
+// resumption to avoid full handshakes on new connections. It is a no-op when the base round tripper is not an *http.Transport.
+func WithTLSSessionCache(capacity int) TransportOption {
+	return withBaseTransport(func(transport *http.Transport) {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(capacity)
+	})
+}
+
+// WithTCPKeepAlive sets the TCP keep-alive period of the connections dialed by the base transport, so dead idle
+// connections are detected before being reused. It wraps the dialer of the base transport, if any, so it can be combined
+// with WithNetworkPreference or WithConnPoolMetrics in any order. It is a no-op when the base round tripper is not an
+// *http.Transport.
+func WithTCPKeepAlive(keepAlive time.Duration) TransportOption {
+	return withBaseTransport(func(transport *http.Transport) {
+		dial := transport.DialContext
+		if dial == nil {
+			dial = newDialer(keepAlive).DialContext
+		}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dial(ctx, network, addr)
+			if err != nil {
+				return nil, err
+			}
+			if err := setKeepAlive(conn, keepAlive); err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return conn, nil
+		}
+	})
+}
+
+type keepAliveConn interface {
+	SetKeepAlive(keepAlive bool) error
+	SetKeepAlivePeriod(period time.Duration) error
+}
+
+// setKeepAlive configures the keep-alive of conn, unwrapping it via NetConn when needed, e.g. for TLS connections.
+// Connections not supporting keep-alive, such as Unix sockets, are left untouched.
+func setKeepAlive(conn net.Conn, keepAlive time.Duration) error {
+	for {
+		if kaConn, ok := conn.(keepAliveConn); ok {
+			if err := kaConn.SetKeepAlive(keepAlive > 0); err != nil {
+				return err
+			}
+			if keepAlive <= 0 {
+				return nil
+			}
+			return kaConn.SetKeepAlivePeriod(keepAlive)
+		}
+		wrapper, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			return nil
+		}
+		conn = wrapper.NetConn()
+	}
+}
+
+// defaultKeepAlive matches the keep-alive period of the http.DefaultTransport dialer.
+const defaultKeepAlive = 30 * time.Second
+
+func newDialer(keepAlive time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: keepAlive,
+	}
+}
+
+// withBaseTransport applies fn to a clone of the base *http.Transport, so the original one, which might be shared
+// (e.g. http.DefaultTransport), is left untouched. It is a no-op when the base round tripper is not an *http.Transport.
+func withBaseTransport(fn func(*http.Transport)) TransportOption {
+	return func(t *HeadersTransport) {
+		base, ok := t.roundTripper.(*http.Transport)
+		if !ok {
+			return
+		}
+		transport := base.Clone()
+		fn(transport)
+		t.roundTripper = transport
+	}
+}
@@ -0,0 +1,231 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithNetworkPreference(t *testing.T) {
+	errDial := errors.New("dial recorded")
+	var dialedNetwork string
+	base := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialedNetwork = network
+			return nil, errDial
+		},
+	}
+	transport := NewHeadersTransport(base, WithNetworkPreference("tcp4"))
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+
+	_, err = transport.RoundTrip(req)
+	assert.ErrorIs(t, err, errDial)
+	assert.Equal(t, "tcp4", dialedNetwork)
+	assert.NotSame(t, base, transport.(*HeadersTransport).roundTripper)
+}
+
+func TestWithNetworkPreferenceNonHTTPTransport(t *testing.T) {
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+	transport := NewHeadersTransport(base, WithNetworkPreference("tcp4"))
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+
+	res, err := transport.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func TestWithTLSSessionCache(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		opts       []TransportOption
+		wantResume bool
+	}{
+		{
+			name:       "session cache",
+			opts:       []TransportOption{WithTLSSessionCache(8)},
+			wantResume: true,
+		},
+		{
+			name:       "no session cache",
+			opts:       nil,
+			wantResume: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := server.Client().Transport.(*http.Transport).Clone()
+			base.DisableKeepAlives = true
+			client := &http.Client{
+				Transport: NewHeadersTransport(base, tt.opts...),
+			}
+
+			var resumed []bool
+			for i := 0; i < 2; i++ {
+				res, err := client.Get(server.URL)
+				assert.NoError(t, err)
+				_, _ = io.Copy(io.Discard, res.Body)
+				assert.NoError(t, res.Body.Close())
+				resumed = append(resumed, res.TLS.DidResume)
+			}
+			assert.Equal(t, []bool{false, tt.wantResume}, resumed)
+		})
+	}
+}
+
+type keepAliveRecorderConn struct {
+	net.Conn
+	keepAlive       bool
+	keepAlivePeriod time.Duration
+}
+
+func (c *keepAliveRecorderConn) SetKeepAlive(keepAlive bool) error {
+	c.keepAlive = keepAlive
+	return nil
+}
+
+func (c *keepAliveRecorderConn) SetKeepAlivePeriod(period time.Duration) error {
+	c.keepAlivePeriod = period
+	return nil
+}
+
+func TestWithTCPKeepAlive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		opts        func(keepAlive TransportOption) []TransportOption
+		wantNetwork string
+	}{
+		{
+			name: "keep-alive only",
+			opts: func(keepAlive TransportOption) []TransportOption {
+				return []TransportOption{keepAlive}
+			},
+			wantNetwork: "tcp",
+		},
+		{
+			name: "before network preference",
+			opts: func(keepAlive TransportOption) []TransportOption {
+				return []TransportOption{keepAlive, WithNetworkPreference("tcp4")}
+			},
+			wantNetwork: "tcp4",
+		},
+		{
+			name: "after network preference",
+			opts: func(keepAlive TransportOption) []TransportOption {
+				return []TransportOption{WithNetworkPreference("tcp4"), keepAlive}
+			},
+			wantNetwork: "tcp4",
+		},
+		{
+			name: "after connection pool metrics",
+			opts: func(keepAlive TransportOption) []TransportOption {
+				return []TransportOption{WithConnPoolMetrics(prometheus.NewRegistry()), keepAlive}
+			},
+			wantNetwork: "tcp",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				dialedNetwork string
+				conns         []*keepAliveRecorderConn
+			)
+			base := &http.Transport{
+				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					dialedNetwork = network
+					conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+					if err != nil {
+						return nil, err
+					}
+					recorder := &keepAliveRecorderConn{Conn: conn}
+					conns = append(conns, recorder)
+					return recorder, nil
+				},
+			}
+			transport := NewHeadersTransport(base, tt.opts(WithTCPKeepAlive(15*time.Second))...)
+
+			res, err := (&http.Client{Transport: transport}).Get(server.URL)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+
+			assert.Equal(t, tt.wantNetwork, dialedNetwork)
+			if assert.Len(t, conns, 1) {
+				assert.True(t, conns[0].keepAlive)
+				assert.Equal(t, 15*time.Second, conns[0].keepAlivePeriod)
+			}
+		})
+	}
+}
+
+func TestNewTransportWithTLS(t *testing.T) {
+	var gotSutureID string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSutureID = r.Header.Get(DefaultSutureIDHeader)
+	}))
+	defer server.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(server.Certificate())
+
+	tests := []struct {
+		name      string
+		tlsConfig *tls.Config
+		wantErr   bool
+	}{
+		{
+			name:      "trusted CA",
+			tlsConfig: &tls.Config{RootCAs: rootCAs},
+		},
+		{
+			name:      "missing CA",
+			tlsConfig: &tls.Config{},
+			wantErr:   true,
+		},
+		{
+			name:    "nil config",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSutureID = ""
+			transport := NewTransportWithTLS(tt.tlsConfig, WithStaticSutureID("suture-id"))
+			base := transport.(*HeadersTransport).roundTripper.(*http.Transport)
+			assert.Equal(t, uint16(tls.VersionTLS12), base.TLSClientConfig.MinVersion)
+
+			client := &http.Client{Transport: transport}
+			res, err := client.Get(server.URL)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+			assert.Equal(t, "suture-id", gotSutureID)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCircuitBreakerThreshold = 5
+	defaultCircuitBreakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned by CircuitBreakerTransport when requests to a host are rejected without being sent.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreakerOption configures a CircuitBreakerTransport.
+type CircuitBreakerOption func(*CircuitBreakerTransport)
+
+// CircuitBreakerTransport fails fast on requests to hosts that keep failing, so an unavailable endpoint doesn't block
+// every caller until it times out. After a number of consecutive failures, the circuit of the host opens and requests
+// fail with ErrCircuitOpen for a cooldown period. Then, a single probe request is sent: the circuit closes if it
+// succeeds, and it opens again otherwise.
+type CircuitBreakerTransport struct {
+	roundTripper http.RoundTripper
+	threshold    int
+	cooldown     time.Duration
+	isFailure    func(*http.Response, error) bool
+
+	mux   sync.Mutex
+	hosts map[string]*circuit
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuit struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func NewCircuitBreakerTransport(rt http.RoundTripper, opts ...CircuitBreakerOption) http.RoundTripper {
+	transport := &CircuitBreakerTransport{
+		roundTripper: rt,
+		threshold:    defaultCircuitBreakerThreshold,
+		cooldown:     defaultCircuitBreakerCooldown,
+		isFailure:    isCircuitFailure,
+		hosts:        make(map[string]*circuit),
+	}
+	if transport.roundTripper == nil {
+		transport.roundTripper = http.DefaultTransport
+	}
+	for _, setOpt := range opts {
+		setOpt(transport)
+	}
+	return transport
+}
+
+// WithCircuitBreakerThreshold sets the number of consecutive failures opening the circuit of a host, 5 by default.
+func WithCircuitBreakerThreshold(n int) CircuitBreakerOption {
+	return func(t *CircuitBreakerTransport) {
+		if n > 0 {
+			t.threshold = n
+		}
+	}
+}
+
+// WithCircuitBreakerCooldown sets how long the circuit of a host stays open before a probe request is sent, 30s by default.
+func WithCircuitBreakerCooldown(cooldown time.Duration) CircuitBreakerOption {
+	return func(t *CircuitBreakerTransport) {
+		t.cooldown = cooldown
+	}
+}
+
+// WithCircuitBreakerFailurePredicate sets the function deciding whether a round trip counts as a failure. By default,
+// errors and 5xx responses are failures.
+func WithCircuitBreakerFailurePredicate(isFailure func(*http.Response, error) bool) CircuitBreakerOption {
+	return func(t *CircuitBreakerTransport) {
+		if isFailure != nil {
+			t.isFailure = isFailure
+		}
+	}
+}
+
+// WrappedRoundTripper returns the wrapped round tripper, so the transport chain can be inspected.
+func (t *CircuitBreakerTransport) WrappedRoundTripper() http.RoundTripper {
+	return t.roundTripper
+}
+
+func (t *CircuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if !t.allow(host) {
+		return nil, fmt.Errorf("%w for host %q", ErrCircuitOpen, host)
+	}
+	res, err := t.roundTripper.RoundTrip(req)
+	// requests canceled by the caller tell nothing about the health of the host
+	if err != nil && req.Context().Err() != nil {
+		t.release(host)
+		return res, err
+	}
+	t.record(host, t.isFailure(res, err))
+	return res, err
+}
+
+func (t *CircuitBreakerTransport) allow(host string) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	c, ok := t.hosts[host]
+	if !ok {
+		return true
+	}
+	switch c.state {
+	case circuitOpen:
+		if time.Since(c.openedAt) < t.cooldown {
+			return false
+		}
+		c.state = circuitHalfOpen
+		c.probing = true
+		return true
+	case circuitHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (t *CircuitBreakerTransport) record(host string, failed bool) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	c, ok := t.hosts[host]
+	if !ok {
+		if !failed {
+			return
+		}
+		c = &circuit{}
+		t.hosts[host] = c
+	}
+	if !failed {
+		delete(t.hosts, host)
+		return
+	}
+	c.failures++
+	if c.state == circuitHalfOpen || c.failures >= t.threshold {
+		c.state = circuitOpen
+		c.openedAt = time.Now()
+		c.probing = false
+	}
+}
+
+func (t *CircuitBreakerTransport) release(host string) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if c, ok := t.hosts[host]; ok {
+		c.probing = false
+	}
+}
+
+func isCircuitFailure(res *http.Response, err error) bool {
+	return err != nil || res.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerTransport(t *testing.T) {
+	var (
+		requests atomic.Int32
+		failing  atomic.Bool
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cooldown := 50 * time.Millisecond
+	client := &http.Client{
+		Transport: NewCircuitBreakerTransport(nil,
+			WithCircuitBreakerThreshold(3),
+			WithCircuitBreakerCooldown(cooldown),
+		),
+	}
+	doRequest := func() (int, error) {
+		res, err := client.Get(server.URL)
+		if err != nil {
+			return 0, err
+		}
+		assert.NoError(t, res.Body.Close())
+		return res.StatusCode, nil
+	}
+
+	// closed: failures are returned until the threshold is reached
+	failing.Store(true)
+	for i := 0; i < 3; i++ {
+		code, err := doRequest()
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, code)
+	}
+	assert.Equal(t, int32(3), requests.Load())
+
+	// open: requests fail fast without reaching the server
+	_, err := doRequest()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(3), requests.Load())
+
+	// half-open: a failing probe opens the circuit again
+	time.Sleep(cooldown)
+	code, err := doRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	_, err = doRequest()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(4), requests.Load())
+
+	// half-open: a successful probe closes the circuit
+	failing.Store(false)
+	time.Sleep(cooldown)
+	code, err = doRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+
+	// closed: a single failure doesn't open the circuit anymore
+	failing.Store(true)
+	code, err = doRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, code)
+	failing.Store(false)
+	code, err = doRequest()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int32(7), requests.Load())
+}
+
+func TestCircuitBreakerTransportSingleProbe(t *testing.T) {
+	probe := make(chan struct{})
+	var requests atomic.Int32
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if requests.Add(1) == 2 {
+			<-probe
+			return newTestResponse(req, http.StatusOK, ""), nil
+		}
+		return nil, errors.New("connection refused")
+	})
+	transport := NewCircuitBreakerTransport(rt,
+		WithCircuitBreakerThreshold(1),
+		WithCircuitBreakerCooldown(0),
+	)
+	newRequest := func() *http.Request {
+		return httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	}
+
+	_, err := transport.RoundTrip(newRequest())
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		res, err := transport.RoundTrip(newRequest())
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}()
+	assert.Eventually(t, func() bool {
+		return requests.Load() == 2
+	}, time.Second, time.Millisecond)
+
+	_, err = transport.RoundTrip(newRequest())
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	close(probe)
+	<-done
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestCircuitBreakerTransportFailurePredicate(t *testing.T) {
+	var requests atomic.Int32
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requests.Add(1)
+		return newTestResponse(req, http.StatusTooManyRequests, ""), nil
+	})
+	transport := NewCircuitBreakerTransport(rt,
+		WithCircuitBreakerThreshold(2),
+		WithCircuitBreakerFailurePredicate(func(res *http.Response, err error) bool {
+			return err != nil || res.StatusCode == http.StatusTooManyRequests
+		}),
+	)
+
+	for i := 0; i < 2; i++ {
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+		assert.NoError(t, err)
+		assert.NoError(t, res.Body.Close())
+	}
+	_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), requests.Load())
+}
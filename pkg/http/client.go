@@ -27,6 +27,7 @@ type Opts struct {
 	headers    map[string]string
 	version    string
 	logger     *logr.Logger
+	redactor   func([]byte) []byte
 
 	tlsEnabled bool
 	tlsCACert  []byte
@@ -88,6 +89,14 @@ func WithLogger(logger *logr.Logger) Option {
 	}
 }
 
+// WithBodyRedactor sets the function applied to request and response bodies before logging them, RedactJSONBody by default.
+func WithBodyRedactor(redactor func([]byte) []byte) Option {
+	return func(opts *Opts) error {
+		opts.redactor = redactor
+		return nil
+	}
+}
+
 func WithTLSEnabled(tlsEnabled bool) Option {
 	return func(opts *Opts) error {
 		opts.tlsEnabled = tlsEnabled
@@ -122,6 +131,7 @@ type Client struct {
 	headers    map[string]string
 	version    string
 	logger     *logr.Logger
+	redactor   func([]byte) []byte
 }
 
 func NewClient(baseUrl string, opts ...Option) (*Client, error) {
@@ -134,7 +144,8 @@ func NewClient(baseUrl string, opts ...Option) (*Client, error) {
 		httpClient: &http.Client{
 			Timeout: defaultTimeout,
 		},
-		headers: make(map[string]string, 0),
+		headers:  make(map[string]string, 0),
+		redactor: RedactJSONBody,
 	}
 	for _, setOpt := range opts {
 		if err := setOpt(&clientOpts); err != nil {
@@ -148,13 +159,18 @@ func NewClient(baseUrl string, opts ...Option) (*Client, error) {
 		headers:    clientOpts.headers,
 		version:    clientOpts.version,
 		logger:     clientOpts.logger,
+		redactor:   clientOpts.redactor,
 	}
 
 	transport, err := client.getTransport(&clientOpts)
 	if err != nil {
 		return nil, fmt.Errorf("error getting transport: %v", err)
 	}
-	client.httpClient.Transport = NewHeadersTransport(transport, client.headers)
+	headersTransport, err := NewHeadersTransportE(transport, WithHeaders(client.headers))
+	if err != nil {
+		return nil, err
+	}
+	client.httpClient.Transport = headersTransport
 	return client, nil
 }
 
@@ -162,7 +178,13 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	if err := c.logRequest(req); err != nil {
 		return nil, fmt.Errorf("error logging request: %v", err)
 	}
-	res, err := c.httpClient.Do(req)
+	httpClient := c.httpClient
+	if timeout, ok := timeoutFromContext(req.Context()); ok {
+		clientWithTimeout := *c.httpClient
+		clientWithTimeout.Timeout = timeout
+		httpClient = &clientWithTimeout
+	}
+	res, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -213,7 +235,7 @@ func (c *Client) logRequest(req *http.Request) error {
 			return err
 		}
 		req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-		c.logDebug("Request body", "body", string(bodyBytes))
+		c.logDebug("Request body", "body", c.redactBody(bodyBytes))
 	}
 	return nil
 }
@@ -226,11 +248,18 @@ func (c *Client) logResponse(req *http.Request, res *http.Response) error {
 			return err
 		}
 		res.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-		c.logDebug("Response body", "body", string(bodyBytes))
+		c.logDebug("Response body", "body", c.redactBody(bodyBytes))
 	}
 	return nil
 }
 
+func (c *Client) redactBody(body []byte) string {
+	if c.redactor == nil {
+		return string(body)
+	}
+	return string(c.redactor(body))
+}
+
 func (c *Client) logInfo(msg string, kv ...interface{}) {
 	if c.logger == nil {
 		return
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientContextWithTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		timeout       time.Duration
+		ctxTimeout    time.Duration
+		setCtxTimeout bool
+		wantErr       bool
+	}{
+		{
+			name:    "global timeout exceeded",
+			timeout: 20 * time.Millisecond,
+			wantErr: true,
+		},
+		{
+			name:          "per-request override longer than global timeout",
+			timeout:       20 * time.Millisecond,
+			ctxTimeout:    time.Second,
+			setCtxTimeout: true,
+			wantErr:       false,
+		},
+		{
+			name:          "per-request override shorter than global timeout",
+			timeout:       time.Second,
+			ctxTimeout:    20 * time.Millisecond,
+			setCtxTimeout: true,
+			wantErr:       true,
+		},
+		{
+			name:          "zero per-request timeout is ignored",
+			timeout:       time.Second,
+			setCtxTimeout: true,
+			wantErr:       false,
+		},
+		{
+			name:          "negative per-request timeout is ignored",
+			timeout:       20 * time.Millisecond,
+			ctxTimeout:    -time.Second,
+			setCtxTimeout: true,
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(server.URL, WithTimeout(tt.timeout))
+			assert.NoError(t, err)
+
+			ctx := context.Background()
+			if tt.setCtxTimeout {
+				ctx = ContextWithTimeout(ctx, tt.ctxTimeout)
+			}
+			res, err := client.Get(ctx, "/", nil)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+		})
+	}
+}
+
+func TestClientBodyRedaction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"users":[{"name":"mariadb","password":"response-secret"}],"token":"abc123"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{
+			name: "default redactor",
+		},
+		{
+			name: "custom redactor",
+			opts: []Option{WithBodyRedactor(func([]byte) []byte { return []byte("redacted by custom") })},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs []string
+			logger := funcr.New(func(prefix, args string) {
+				logs = append(logs, args)
+			}, funcr.Options{Verbosity: 1})
+
+			client, err := NewClient(server.URL, append([]Option{WithLogger(&logger)}, tt.opts...)...)
+			assert.NoError(t, err)
+
+			res, err := client.Post(context.Background(), "/users", map[string]string{
+				"name":     "mariadb",
+				"password": "request-secret",
+			}, nil)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+
+			allLogs := strings.Join(logs, "\n")
+			assert.Contains(t, allLogs, "Request body")
+			assert.Contains(t, allLogs, "Response body")
+			for _, secret := range []string{"request-secret", "response-secret", "abc123"} {
+				assert.NotContains(t, allLogs, secret)
+			}
+		})
+	}
+}
+
+func TestRedactJSONBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "nested keys",
+			body: `{"name":"mariadb","rootPassword":"secret","spec":{"apiKey":"key","replicas":3}}`,
+			want: `{"name":"mariadb","rootPassword":"[REDACTED]","spec":{"apiKey":"[REDACTED]","replicas":3}}`,
+		},
+		{
+			name: "arrays",
+			body: `[{"user":"root","password":"secret"}]`,
+			want: `[{"password":"[REDACTED]","user":"root"}]`,
+		},
+		{
+			name: "numbers",
+			body: `{"id":12345678901234567890,"ratio":0.1,"token":42}`,
+			want: `{"id":12345678901234567890,"ratio":0.1,"token":"[REDACTED]"}`,
+		},
+		{
+			name: "not JSON",
+			body: `password=secret`,
+			want: `password=secret`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(RedactJSONBody([]byte(tt.body))))
+		})
+	}
+}
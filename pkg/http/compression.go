@@ -0,0 +1,137 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"sync"
+)
+
+const defaultRequestCompressionMinBytes = 1024
+
+// WithRequestCompression gzip-compresses request bodies of at least 1KiB, see WithRequestCompressionMinBytes, setting
+// Content-Encoding: gzip. Bodies already carrying a Content-Encoding are sent as is. The compressed body is buffered, so
+// the request has an accurate Content-Length and can be replayed via GetBody, e.g. by RetryTransport.
+func WithRequestCompression() TransportOption {
+	return func(t *HeadersTransport) {
+		t.requestCompression = true
+	}
+}
+
+// WithRequestCompressionMinBytes sets the minimum size of the request bodies compressed by WithRequestCompression.
+func WithRequestCompressionMinBytes(minBytes int) TransportOption {
+	return func(t *HeadersTransport) {
+		t.requestCompressionMinBytes = minBytes
+	}
+}
+
+func (t *HeadersTransport) compressRequest(req *http.Request) error {
+	if !t.requestCompression || req.Body == nil || req.Body == http.NoBody ||
+		req.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	minBytes := t.requestCompressionMinBytes
+	if minBytes <= 0 {
+		minBytes = defaultRequestCompressionMinBytes
+	}
+	if req.ContentLength > 0 && req.ContentLength < int64(minBytes) {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	if len(body) < minBytes {
+		setRequestBody(req, body)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	if _, err := gzipWriter.Write(body); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return err
+	}
+	setRequestBody(req, buf.Bytes())
+	req.Header.Set("Content-Encoding", "gzip")
+	return nil
+}
+
+func setRequestBody(req *http.Request, body []byte) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Del("Content-Length")
+}
+
+// WithGzipResponseOnReturn gzip-compresses uncompressed response bodies of at least minBytes before returning them,
+// setting Content-Encoding: gzip, e.g. to relay them to bandwidth constrained consumers. Bodies of unknown length are
+// always compressed, as their size is only known once read. The compression is streamed, starting on the first Read, and
+// closing the returned body releases the original one.
+func WithGzipResponseOnReturn(minBytes int) TransportOption {
+	return func(t *HeadersTransport) {
+		t.gzipResponseMinBytes = minBytes
+	}
+}
+
+func (t *HeadersTransport) gzipResponse(res *http.Response) error {
+	if t.gzipResponseMinBytes <= 0 || res.Body == nil || res.Body == http.NoBody ||
+		res.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	if res.ContentLength >= 0 && res.ContentLength < int64(t.gzipResponseMinBytes) {
+		return nil
+	}
+
+	pr, pw := io.Pipe()
+	res.Body = &gzipBody{
+		pr:       pr,
+		pw:       pw,
+		original: res.Body,
+	}
+	res.Header.Set("Content-Encoding", "gzip")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = false
+	return nil
+}
+
+// gzipBody compresses the original body on its first Read, so returning the response never waits for the body, e.g.
+// the events of a WATCH.
+type gzipBody struct {
+	pr       *io.PipeReader
+	pw       *io.PipeWriter
+	original io.ReadCloser
+	once     sync.Once
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	b.once.Do(func() {
+		go func() {
+			gzipWriter := gzip.NewWriter(b.pw)
+			_, err := io.Copy(gzipWriter, b.original)
+			if err == nil {
+				err = gzipWriter.Close()
+			}
+			b.pw.CloseWithError(err)
+		}()
+	})
+	return b.pr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	_ = b.pr.Close()
+	return b.original.Close()
+}
+
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,229 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithGzipResponseOnReturn(t *testing.T) {
+	large := strings.Repeat("mariadb-operator ", 100)
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		encoding      string
+		wantGzip      bool
+	}{
+		{
+			name:          "large body",
+			body:          large,
+			contentLength: int64(len(large)),
+			wantGzip:      true,
+		},
+		{
+			name:          "large body of unknown length",
+			body:          large,
+			contentLength: -1,
+			wantGzip:      true,
+		},
+		{
+			name:          "small body",
+			body:          "small",
+			contentLength: 5,
+			wantGzip:      false,
+		},
+		{
+			name:          "small body of unknown length",
+			body:          "small",
+			contentLength: -1,
+			wantGzip:      true,
+		},
+		{
+			name:          "already encoded",
+			body:          large,
+			contentLength: int64(len(large)),
+			encoding:      "br",
+			wantGzip:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				res := newTestResponse(req, http.StatusOK, tt.body)
+				res.ContentLength = tt.contentLength
+				if tt.encoding != "" {
+					res.Header.Set("Content-Encoding", tt.encoding)
+				}
+				return res, nil
+			})
+			transport := NewHeadersTransport(base, WithGzipResponseOnReturn(512))
+
+			req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+
+			res, err := transport.RoundTrip(req)
+			assert.NoError(t, err)
+			defer res.Body.Close()
+
+			body := res.Body
+			if tt.wantGzip {
+				assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+				assert.Equal(t, int64(-1), res.ContentLength)
+
+				gzipReader, err := gzip.NewReader(res.Body)
+				assert.NoError(t, err)
+				body = gzipReader
+			} else {
+				assert.Equal(t, tt.encoding, res.Header.Get("Content-Encoding"))
+			}
+			bytes, err := io.ReadAll(body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(bytes))
+		})
+	}
+}
+
+func TestWithGzipResponseOnReturnStreamingBody(t *testing.T) {
+	pr, pw := io.Pipe()
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		res := newTestResponse(req, http.StatusOK, "")
+		res.Body = pr
+		res.ContentLength = -1
+		return res, nil
+	})
+	transport := NewHeadersTransport(base, WithGzipResponseOnReturn(512))
+
+	done := make(chan *http.Response)
+	go func() {
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com/watch", nil))
+		assert.NoError(t, err)
+		done <- res
+	}()
+
+	var res *http.Response
+	select {
+	case res = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RoundTrip blocked on the body of a streaming response")
+	}
+	defer res.Body.Close()
+	assert.Equal(t, "gzip", res.Header.Get("Content-Encoding"))
+
+	go func() {
+		_, _ = pw.Write([]byte("event"))
+		pw.Close()
+	}()
+	gzipReader, err := gzip.NewReader(res.Body)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(gzipReader)
+	assert.NoError(t, err)
+	assert.Equal(t, "event", string(body))
+}
+
+func TestWithRequestCompression(t *testing.T) {
+	large := strings.Repeat("mariadb-operator ", 100)
+	tests := []struct {
+		name     string
+		body     string
+		encoding string
+		wantGzip bool
+	}{
+		{
+			name:     "large body",
+			body:     large,
+			wantGzip: true,
+		},
+		{
+			name: "small body",
+			body: "small",
+		},
+		{
+			name:     "already encoded",
+			body:     large,
+			encoding: "identity",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotEncoding      string
+				gotContentLength int64
+				gotBody          []byte
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotEncoding = r.Header.Get("Content-Encoding")
+				gotContentLength = r.ContentLength
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				gotBody = body
+			}))
+			defer server.Close()
+
+			client := &http.Client{
+				Transport: NewHeadersTransport(nil, WithRequestCompression(), WithRequestCompressionMinBytes(64)),
+			}
+			req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			res, err := client.Do(req)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+
+			assert.Equal(t, int64(len(gotBody)), gotContentLength)
+			if !tt.wantGzip {
+				assert.Equal(t, tt.encoding, gotEncoding)
+				assert.Equal(t, tt.body, string(gotBody))
+				return
+			}
+			assert.Equal(t, "gzip", gotEncoding)
+			assert.Less(t, len(gotBody), len(tt.body))
+			gzipReader, err := gzip.NewReader(bytes.NewReader(gotBody))
+			assert.NoError(t, err)
+			decompressed, err := io.ReadAll(gzipReader)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(decompressed))
+		})
+	}
+}
+
+func TestWithRequestCompressionReplayable(t *testing.T) {
+	large := strings.Repeat("mariadb-operator ", 100)
+	var bodies [][]byte
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+		bodies = append(bodies, body)
+		if len(bodies) == 1 {
+			return newTestResponse(req, http.StatusBadGateway, ""), nil
+		}
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+	transport := NewHeadersTransport(NewRetryTransport(rt, WithRetryBackoff(0, 0)), WithRequestCompression())
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", io.NopCloser(strings.NewReader(large)))
+	assert.NoError(t, err)
+	res, err := transport.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	if assert.Len(t, bodies, 2) {
+		assert.Equal(t, bodies[0], bodies[1])
+		gzipReader, err := gzip.NewReader(bytes.NewReader(bodies[1]))
+		assert.NoError(t, err)
+		decompressed, err := io.ReadAll(gzipReader)
+		assert.NoError(t, err)
+		assert.Equal(t, large, string(decompressed))
+	}
+}
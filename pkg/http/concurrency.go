@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithMethodConcurrencyLimit bounds the number of in-flight requests per HTTP method, each method having its own limit.
+// A request holds its slot until the response headers are received. Methods not present in the map are not limited.
+func WithMethodConcurrencyLimit(limits map[string]int) TransportOption {
+	return func(t *HeadersTransport) {
+		t.methodSemaphores = make(map[string]chan struct{}, len(limits))
+		for method, limit := range limits {
+			if limit > 0 {
+				t.methodSemaphores[strings.ToUpper(method)] = make(chan struct{}, limit)
+			}
+		}
+	}
+}
+
+// WithMaxConnsPerHost bounds the number of in-flight requests per host, regardless of the base round tripper type.
+// Unlike http.Transport.MaxConnsPerHost, it is enforced at the transport level, so it also applies to custom round trippers.
+// A request holds its slot until its response body is closed, so requests streaming their response, e.g. watches, keep
+// counting against the limit.
+func WithMaxConnsPerHost(n int) TransportOption {
+	return func(t *HeadersTransport) {
+		if n <= 0 {
+			t.hostSemaphores = nil
+			return
+		}
+		t.hostSemaphores = &hostSemaphores{
+			limit: n,
+		}
+	}
+}
+
+type hostSemaphores struct {
+	limit      int
+	mux        sync.Mutex
+	semaphores map[string]chan struct{}
+}
+
+func (h *hostSemaphores) get(host string) chan struct{} {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	if h.semaphores == nil {
+		h.semaphores = make(map[string]chan struct{})
+	}
+	sem, ok := h.semaphores[host]
+	if !ok {
+		sem = make(chan struct{}, h.limit)
+		h.semaphores[host] = sem
+	}
+	return sem
+}
+
+// WithInFlightThrottle slows down, rather than rejects, new requests when the number of in-flight requests exceeds
+// softLimit: each request is delayed by step for every in-flight request beyond softLimit, including itself.
+func WithInFlightThrottle(softLimit int, step time.Duration) TransportOption {
+	return func(t *HeadersTransport) {
+		t.throttleSoftLimit = softLimit
+		t.throttleStep = step
+	}
+}
+
+type inFlightThrottle struct {
+	inFlight atomic.Int64
+}
+
+func (t *HeadersTransport) throttle(req *http.Request) (func(), error) {
+	if t.throttleStep <= 0 {
+		return func() {}, nil
+	}
+	inFlight := t.inFlightThrottle.inFlight.Add(1)
+	release := func() { t.inFlightThrottle.inFlight.Add(-1) }
+
+	excess := inFlight - int64(t.throttleSoftLimit)
+	if excess <= 0 {
+		return release, nil
+	}
+	timer := time.NewTimer(time.Duration(excess) * t.throttleStep)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return release, nil
+	case <-req.Context().Done():
+		release()
+		return nil, req.Context().Err()
+	}
+}
+
+// acquireSemaphores acquires the concurrency slots of req. The first returned function releases the slots held until the
+// response headers are received, whereas the second one releases the per-host slot, held until the response body is closed.
+func (t *HeadersTransport) acquireSemaphores(req *http.Request) (func(), func(), error) {
+	releaseHost, err := t.acquireHostSemaphore(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	var releases []func()
+	release := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+	for _, acquire := range []func(*http.Request) (func(), error){
+		t.acquireMethodSemaphore,
+		acquireContextSemaphore,
+		t.throttle,
+	} {
+		releaseSemaphore, err := acquire(req)
+		if err != nil {
+			release()
+			releaseHost()
+			return nil, nil, err
+		}
+		releases = append(releases, releaseSemaphore)
+	}
+	return release, sync.OnceFunc(releaseHost), nil
+}
+
+func (t *HeadersTransport) acquireMethodSemaphore(req *http.Request) (func(), error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	sem, ok := t.methodSemaphores[strings.ToUpper(method)]
+	if !ok {
+		return func() {}, nil
+	}
+	return acquireSemaphore(req.Context(), sem)
+}
+
+func (t *HeadersTransport) acquireHostSemaphore(req *http.Request) (func(), error) {
+	if t.hostSemaphores == nil {
+		return func() {}, nil
+	}
+	return acquireSemaphore(req.Context(), t.hostSemaphores.get(req.URL.Host))
+}
+
+func acquireContextSemaphore(req *http.Request) (func(), error) {
+	sem, ok := concurrencyLimitFromContext(req.Context())
+	if !ok {
+		return func() {}, nil
+	}
+	return acquireSemaphore(req.Context(), sem)
+}
+
+func acquireSemaphore(ctx context.Context, sem chan struct{}) (func(), error) {
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMethodConcurrencyLimit(t *testing.T) {
+	var (
+		mux         sync.Mutex
+		inFlight    = map[string]int{}
+		maxInFlight = map[string]int{}
+	)
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mux.Lock()
+		inFlight[req.Method]++
+		maxInFlight[req.Method] = max(maxInFlight[req.Method], inFlight[req.Method])
+		mux.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		mux.Lock()
+		inFlight[req.Method]--
+		mux.Unlock()
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+	transport := NewHeadersTransport(base, WithMethodConcurrencyLimit(map[string]int{
+		http.MethodGet:  5,
+		http.MethodPost: 2,
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, method := range []string{http.MethodGet, http.MethodPost} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req, err := http.NewRequest(method, "http://example.com", nil)
+				assert.NoError(t, err)
+				_, err = transport.RoundTrip(req)
+				assert.NoError(t, err)
+			}()
+		}
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxInFlight[http.MethodPost], 2)
+	assert.LessOrEqual(t, maxInFlight[http.MethodGet], 5)
+	assert.Greater(t, maxInFlight[http.MethodGet], 2)
+}
+
+func TestWithMethodConcurrencyLimitNormalizesMethod(t *testing.T) {
+	transport := NewHeadersTransport(nil, WithMethodConcurrencyLimit(map[string]int{
+		"get": 1,
+	})).(*HeadersTransport)
+
+	for _, method := range []string{"", "get", http.MethodGet} {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Method = method
+		release, err := transport.acquireMethodSemaphore(req)
+		assert.NoError(t, err)
+		assert.Len(t, transport.methodSemaphores[http.MethodGet], 1, "method %q", method)
+		release()
+	}
+}
+
+func TestContextWithConcurrencyLimit(t *testing.T) {
+	var (
+		inFlight    atomic.Int32
+		maxInFlight atomic.Int32
+	)
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		current := inFlight.Add(1)
+		for {
+			observed := maxInFlight.Load()
+			if current <= observed || maxInFlight.CompareAndSwap(observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		inFlight.Add(-1)
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+	transport := NewHeadersTransport(base)
+	ctx := ContextWithConcurrencyLimit(context.Background(), 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+			_, err = transport.RoundTrip(req)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(3), maxInFlight.Load())
+}
+
+func TestWithMaxConnsPerHost(t *testing.T) {
+	var (
+		mux         sync.Mutex
+		inFlight    = map[string]int{}
+		maxInFlight = map[string]int{}
+	)
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		mux.Lock()
+		inFlight[req.URL.Host]++
+		maxInFlight[req.URL.Host] = max(maxInFlight[req.URL.Host], inFlight[req.URL.Host])
+		mux.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mux.Lock()
+		inFlight[req.URL.Host]--
+		mux.Unlock()
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+	transport := NewHeadersTransport(base, WithMaxConnsPerHost(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, host := range []string{"a.example.com", "b.example.com"} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req, err := http.NewRequest(http.MethodGet, "http://"+host, nil)
+				assert.NoError(t, err)
+				res, err := transport.RoundTrip(req)
+				assert.NoError(t, err)
+				assert.NoError(t, res.Body.Close())
+			}()
+		}
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, maxInFlight["a.example.com"])
+	assert.Equal(t, 2, maxInFlight["b.example.com"])
+}
+
+func TestWithMaxConnsPerHostStreamingBody(t *testing.T) {
+	var sent atomic.Int32
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sent.Add(1)
+		return newTestResponse(req, http.StatusOK, "event"), nil
+	})
+	transport := NewHeadersTransport(base, WithMaxConnsPerHost(1))
+
+	res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com/watch", nil))
+	assert.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+		assert.NoError(t, err)
+		assert.NoError(t, res.Body.Close())
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("second request sent while the body of the first one is open")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, int32(1), sent.Load())
+
+	assert.NoError(t, res.Body.Close())
+	assert.NoError(t, res.Body.Close())
+	<-done
+	assert.Equal(t, int32(2), sent.Load())
+}
+
+func TestWithInFlightThrottle(t *testing.T) {
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		time.Sleep(20 * time.Millisecond)
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+	transport := NewHeadersTransport(base, WithInFlightThrottle(2, 10*time.Millisecond))
+
+	maxLatency := func(concurrency int) time.Duration {
+		var (
+			mux     sync.Mutex
+			latency time.Duration
+			wg      sync.WaitGroup
+		)
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+				assert.NoError(t, err)
+
+				start := time.Now()
+				_, err = transport.RoundTrip(req)
+				assert.NoError(t, err)
+
+				mux.Lock()
+				latency = max(latency, time.Since(start))
+				mux.Unlock()
+			}()
+		}
+		wg.Wait()
+		return latency
+	}
+
+	low := maxLatency(1)
+	high := maxLatency(10)
+	assert.Less(t, low, 40*time.Millisecond)
+	assert.Greater(t, high, low+40*time.Millisecond)
+}
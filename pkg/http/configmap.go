@@ -0,0 +1,127 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync/atomic"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// HeaderSource provides headers that may change over time, e.g. a ConfigMapHeaderSource.
+// Headers must return a map that is not mutated afterwards, as it is read concurrently by requests.
+type HeaderSource interface {
+	Headers() map[string]string
+}
+
+// WithHeaderSource sends the headers provided by src with every request, merged on top of the static ones.
+// The source is shared with the clones of the transport.
+func WithHeaderSource(src HeaderSource) TransportOption {
+	return func(t *HeadersTransport) {
+		t.headerSource = src
+	}
+}
+
+// ConfigMapHeaderSource is a HeaderSource providing the key/value pairs of a ConfigMap as headers, e.g. operator-wide
+// headers like the environment name. The ConfigMap is watched, so updates are applied without restarting the process.
+// No headers are provided while the ConfigMap does not exist, and updates with invalid headers are discarded, keeping
+// the last valid ones.
+type ConfigMapHeaderSource struct {
+	name     string
+	informer cache.SharedIndexInformer
+	store    headerStore
+	err      atomic.Pointer[error]
+}
+
+// NewConfigMapHeaderSource creates a ConfigMapHeaderSource and starts watching the ConfigMap until ctx is done.
+// A single source should be created and shared by all the transports using it, see WithHeaderSource.
+func NewConfigMapHeaderSource(ctx context.Context, client kubernetes.Interface, namespace,
+	name string) *ConfigMapHeaderSource {
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", name).String()
+	listWatch := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+			opts.FieldSelector = fieldSelector
+			return client.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+		},
+		WatchFuncWithContext: func(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+			opts.FieldSelector = fieldSelector
+			return client.CoreV1().ConfigMaps(namespace).Watch(ctx, opts)
+		},
+	}
+	src := &ConfigMapHeaderSource{
+		name:     name,
+		informer: cache.NewSharedIndexInformer(listWatch, &corev1.ConfigMap{}, 0, cache.Indexers{}),
+	}
+	_, _ = src.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			src.setFromConfigMap(obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			src.setFromConfigMap(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if configMap, ok := obj.(*corev1.ConfigMap); !ok || configMap.Name == name {
+				src.store.set(nil)
+				src.err.Store(nil)
+			}
+		},
+	})
+	go src.informer.RunWithContext(ctx)
+	return src
+}
+
+// Headers returns the headers of the last valid version of the ConfigMap.
+func (s *ConfigMapHeaderSource) Headers() map[string]string {
+	return s.store.get()
+}
+
+// HasSynced returns whether the ConfigMap has been initially listed.
+func (s *ConfigMapHeaderSource) HasSynced() bool {
+	return s.informer.HasSynced()
+}
+
+// Err returns the error of the last version of the ConfigMap when it contains invalid headers, nil otherwise.
+func (s *ConfigMapHeaderSource) Err() error {
+	if err := s.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func (s *ConfigMapHeaderSource) setFromConfigMap(obj interface{}) {
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok || configMap.Name != s.name {
+		return
+	}
+	if err := validateHeaders(configMap.Data); err != nil {
+		err = fmt.Errorf("error validating headers from ConfigMap '%s/%s': %v", configMap.Namespace, configMap.Name, err)
+		s.err.Store(&err)
+		return
+	}
+	s.store.set(maps.Clone(configMap.Data))
+	s.err.Store(nil)
+}
+
+// headerStore holds headers updated concurrently with requests. Updates replace the whole map, which is never mutated
+// afterwards, so requests can read it without locking.
+type headerStore struct {
+	headers atomic.Pointer[map[string]string]
+}
+
+func (s *headerStore) get() map[string]string {
+	if headers := s.headers.Load(); headers != nil {
+		return *headers
+	}
+	return nil
+}
+
+func (s *headerStore) set(headers map[string]string) {
+	s.headers.Store(&headers)
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigMapHeaderSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "headers",
+			Namespace: "default",
+		},
+		Data: map[string]string{
+			"X-Environment": "staging",
+		},
+	}
+	client := fake.NewClientset(configMap)
+
+	src := NewConfigMapHeaderSource(ctx, client, "default", "headers")
+	var gotHeaders http.Header
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotHeaders = req.Header.Clone()
+		return newTestResponse(req, http.StatusOK, ""), nil
+	}), WithHeaders(map[string]string{
+		"X-Operator": "mariadb-operator",
+	}), WithHeaderSource(src))
+
+	headerEventually := func(name, value string) {
+		assert.Eventually(t, func() bool {
+			req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+			_, err = transport.RoundTrip(req)
+			assert.NoError(t, err)
+			return gotHeaders.Get(name) == value
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	headerEventually("X-Environment", "staging")
+	assert.Equal(t, "mariadb-operator", gotHeaders.Get("X-Operator"))
+
+	configMap.Data["X-Environment"] = "production"
+	_, err := client.CoreV1().ConfigMaps("default").Update(ctx, configMap, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	headerEventually("X-Environment", "production")
+
+	configMap.Data["X-Environment"] = "bad\nvalue"
+	_, err = client.CoreV1().ConfigMaps("default").Update(ctx, configMap, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return src.Err() != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	headerEventually("X-Environment", "production")
+
+	err = client.CoreV1().ConfigMaps("default").Delete(ctx, "headers", metav1.DeleteOptions{})
+	assert.NoError(t, err)
+	headerEventually("X-Environment", "")
+	assert.Equal(t, "mariadb-operator", gotHeaders.Get("X-Operator"))
+}
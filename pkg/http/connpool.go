@@ -0,0 +1,185 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WithConnPoolMetrics publishes the number of active and idle connections per host of the base transport as gauges,
+// registered against registerer. A connection is active while it serves at least one request, until the response body
+// is closed, and idle otherwise, so HTTP/2 connections multiplexing several requests are supported as well. The gauges
+// are updated as connections are dialed and closed, and as requests acquire and release them. If the gauges are already
+// registered, the registered ones are reused. It is a no-op when the base round tripper is not an *http.Transport.
+func WithConnPoolMetrics(registerer prometheus.Registerer) TransportOption {
+	return func(t *HeadersTransport) {
+		if _, ok := t.roundTripper.(*http.Transport); !ok {
+			return
+		}
+		pool, err := newConnPoolMetrics(registerer)
+		if err != nil {
+			t.connPoolErr = err
+			return
+		}
+		withBaseTransport(func(transport *http.Transport) {
+			dial := transport.DialContext
+			if dial == nil {
+				dial = newDialer(defaultKeepAlive).DialContext
+			}
+			transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+				conn, err := dial(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+				return pool.track(conn, addr), nil
+			}
+		})(t)
+		t.connPool = pool
+	}
+}
+
+type connPoolMetrics struct {
+	active *prometheus.GaugeVec
+	idle   *prometheus.GaugeVec
+	mux    sync.Mutex
+}
+
+func newConnPoolMetrics(registerer prometheus.Registerer) (*connPoolMetrics, error) {
+	active, err := registerCollector(registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "active_connections",
+		Help:      "Number of connections in use per host.",
+	}, []string{"host"}))
+	if err != nil {
+		return nil, err
+	}
+	idle, err := registerCollector(registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "idle_connections",
+		Help:      "Number of idle connections in the pool per host.",
+	}, []string{"host"}))
+	if err != nil {
+		return nil, err
+	}
+	return &connPoolMetrics{
+		active: active,
+		idle:   idle,
+	}, nil
+}
+
+// track wraps a newly dialed connection, which is idle until a request acquires it. It may not be used by the request
+// that dialed it, e.g. when another connection became available in the meantime.
+func (p *connPoolMetrics) track(conn net.Conn, host string) net.Conn {
+	p.idle.WithLabelValues(host).Inc()
+	return &trackedConn{
+		Conn: conn,
+		pool: p,
+		host: host,
+	}
+}
+
+// withClientTrace returns a copy of req acquiring the connection serving it, along with a function releasing the
+// connection, to be called once the request is done.
+func (p *connPoolMetrics) withClientTrace(req *http.Request) (*http.Request, func()) {
+	var (
+		mux  sync.Mutex
+		conn *trackedConn
+		done bool
+	)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			mux.Lock()
+			defer mux.Unlock()
+			if done || conn != nil {
+				return
+			}
+			conn = unwrapTrackedConn(info.Conn)
+			if conn != nil {
+				p.acquire(conn)
+			}
+		},
+	}
+	release := func() {
+		mux.Lock()
+		defer mux.Unlock()
+		if done {
+			return
+		}
+		done = true
+		if conn != nil {
+			p.release(conn)
+		}
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), release
+}
+
+func (p *connPoolMetrics) acquire(conn *trackedConn) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	conn.requests++
+	if conn.closed || conn.requests > 1 {
+		return
+	}
+	p.idle.WithLabelValues(conn.host).Dec()
+	p.active.WithLabelValues(conn.host).Inc()
+}
+
+func (p *connPoolMetrics) release(conn *trackedConn) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	conn.requests--
+	if conn.closed || conn.requests > 0 {
+		return
+	}
+	p.active.WithLabelValues(conn.host).Dec()
+	p.idle.WithLabelValues(conn.host).Inc()
+}
+
+func (p *connPoolMetrics) close(conn *trackedConn) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	if conn.closed {
+		return
+	}
+	conn.closed = true
+	if conn.requests > 0 {
+		p.active.WithLabelValues(conn.host).Dec()
+	} else {
+		p.idle.WithLabelValues(conn.host).Dec()
+	}
+}
+
+type trackedConn struct {
+	net.Conn
+	pool *connPoolMetrics
+	host string
+	// requests and closed are guarded by the pool mutex.
+	requests int
+	closed   bool
+}
+
+// NetConn returns the underlying connection.
+func (c *trackedConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func (c *trackedConn) Close() error {
+	c.pool.close(c)
+	return c.Conn.Close()
+}
+
+func unwrapTrackedConn(conn net.Conn) *trackedConn {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	tracked, _ := conn.(*trackedConn)
+	return tracked
+}
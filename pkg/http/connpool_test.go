@@ -0,0 +1,104 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithConnPoolMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	host := serverURL.Host
+
+	registry := prometheus.NewRegistry()
+	transport := NewHeadersTransport(&http.Transport{}, WithConnPoolMetrics(registry)).(*HeadersTransport)
+	client := &http.Client{Transport: transport}
+
+	gauges := func() (float64, float64) {
+		return testutil.ToFloat64(transport.connPool.active.WithLabelValues(host)),
+			testutil.ToFloat64(transport.connPool.idle.WithLabelValues(host))
+	}
+	assertGauges := func(wantActive, wantIdle float64) {
+		assert.Eventually(t, func() bool {
+			active, idle := gauges()
+			return active == wantActive && idle == wantIdle
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assertGauges(1, 0)
+
+	_, err = io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+	assertGauges(0, 1)
+
+	res, err = client.Get(server.URL)
+	assert.NoError(t, err)
+	assertGauges(1, 0)
+	_, err = io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+	assertGauges(0, 1)
+
+	transport.roundTripper.(*http.Transport).CloseIdleConnections()
+	assertGauges(0, 0)
+
+	other := NewHeadersTransport(&http.Transport{}, WithConnPoolMetrics(registry)).(*HeadersTransport)
+	assert.NoError(t, other.configErr)
+	assert.Equal(t, transport.connPool.active, other.connPool.active)
+}
+
+func TestWithConnPoolMetricsHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	host := serverURL.Host
+
+	base := server.Client().Transport.(*http.Transport)
+	transport := NewHeadersTransport(base, WithConnPoolMetrics(prometheus.NewRegistry())).(*HeadersTransport)
+	client := &http.Client{Transport: transport}
+
+	assertGauges := func(wantActive, wantIdle float64) {
+		assert.Eventually(t, func() bool {
+			return testutil.ToFloat64(transport.connPool.active.WithLabelValues(host)) == wantActive &&
+				testutil.ToFloat64(transport.connPool.idle.WithLabelValues(host)) == wantIdle
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	first, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, first.ProtoMajor)
+	second, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, second.ProtoMajor)
+	assertGauges(1, 0)
+
+	assert.NoError(t, first.Body.Close())
+	assertGauges(1, 0)
+	assert.NoError(t, second.Body.Close())
+	assertGauges(0, 1)
+
+	transport.roundTripper.(*http.Transport).CloseIdleConnections()
+	assertGauges(0, 0)
+}
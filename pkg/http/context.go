@@ -0,0 +1,200 @@
+package http
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+type faultContextKey struct{}
+
+type fault struct {
+	delay time.Duration
+	err   error
+}
+
+// ContextWithFault returns a copy of ctx that makes HeadersTransport inject a fault into the requests that carry it.
+// The request is delayed by delay and, when err is not nil, it fails with err without being sent.
+// It is meant for targeted chaos testing, as only the requests using the returned context are affected.
+func ContextWithFault(ctx context.Context, delay time.Duration, err error) context.Context {
+	return context.WithValue(ctx, faultContextKey{}, fault{
+		delay: delay,
+		err:   err,
+	})
+}
+
+func injectFault(req *http.Request) error {
+	ctx := req.Context()
+	f, ok := ctx.Value(faultContextKey{}).(fault)
+	if !ok {
+		return nil
+	}
+	if f.delay > 0 {
+		timer := time.NewTimer(f.delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return f.err
+}
+
+type sessionKeyContextKey struct{}
+
+// ContextWithSessionKey returns a copy of ctx carrying a logical session key.
+// When WithSessionAffinity is enabled, requests sharing the same session key reuse the same upstream connection.
+func ContextWithSessionKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, sessionKeyContextKey{}, key)
+}
+
+func sessionKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(sessionKeyContextKey{}).(string)
+	return key, ok
+}
+
+type concurrencyLimitContextKey struct{}
+
+// ContextWithConcurrencyLimit returns a copy of ctx that bounds to n the number of concurrent in-flight requests
+// sharing it, e.g. all the API calls issued by a single reconcile. Derived contexts share the same limit.
+func ContextWithConcurrencyLimit(ctx context.Context, n int) context.Context {
+	if n <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, concurrencyLimitContextKey{}, make(chan struct{}, n))
+}
+
+func concurrencyLimitFromContext(ctx context.Context) (chan struct{}, bool) {
+	sem, ok := ctx.Value(concurrencyLimitContextKey{}).(chan struct{})
+	return sem, ok
+}
+
+type latencyContextKey struct{}
+
+// LatencyFromResponse returns the round-trip latency recorded by HeadersTransport when WithResponseLatency is enabled.
+func LatencyFromResponse(res *http.Response) (time.Duration, bool) {
+	if res == nil || res.Request == nil {
+		return 0, false
+	}
+	latency, ok := res.Request.Context().Value(latencyContextKey{}).(time.Duration)
+	return latency, ok
+}
+
+func setResponseLatency(res *http.Response, latency time.Duration) {
+	if res.Request == nil {
+		return
+	}
+	res.Request = res.Request.WithContext(context.WithValue(res.Request.Context(), latencyContextKey{}, latency))
+}
+
+type timeoutContextKey struct{}
+
+// ContextWithTimeout returns a copy of ctx carrying a per-request timeout. HeadersTransport applies it to the requests
+// that carry it and Client uses it in place of the timeout configured via WithTimeout. Non-positive timeouts are ignored.
+func ContextWithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutContextKey{}, timeout)
+}
+
+func timeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(timeoutContextKey{}).(time.Duration)
+	if !ok || timeout <= 0 {
+		return 0, false
+	}
+	return timeout, true
+}
+
+type headersContextKey struct{}
+
+func contextWithHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return context.WithValue(ctx, headersContextKey{}, headers)
+}
+
+func headersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersContextKey{}).(map[string]string)
+	return headers
+}
+
+type inheritedHeadersContextKey struct{}
+
+// ContextWithInheritedHeaders returns a copy of ctx carrying the named headers of a parent request, e.g. an incoming
+// request being proxied. HeadersTransport copies them onto the outbound requests using the returned context, on top of
+// its configured headers, as long as they are allowed by WithForwardHeaderAllowlist. Headers not present in parent are
+// ignored.
+func ContextWithInheritedHeaders(ctx context.Context, parent http.Header, names ...string) context.Context {
+	inherited := make(http.Header, len(names))
+	for _, name := range names {
+		if values := parent.Values(name); len(values) > 0 {
+			inherited[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+		}
+	}
+	return context.WithValue(ctx, inheritedHeadersContextKey{}, inherited)
+}
+
+func inheritedHeadersFromContext(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(inheritedHeadersContextKey{}).(http.Header)
+	return headers
+}
+
+type sutureIDContextKey struct{}
+
+// WithSutureID returns a copy of ctx carrying a Suture ID, which HeadersTransport sends in place of the one read from
+// the environment. It allows reconcilers to stamp a per-object correlation ID into the API calls they trigger.
+func WithSutureID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sutureIDContextKey{}, id)
+}
+
+// SutureIDFromContext returns the Suture ID set via WithSutureID.
+func SutureIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(sutureIDContextKey{}).(string)
+	return id, ok
+}
+
+// ContextWithSutureIDFromRequest returns a copy of parent carrying the Suture ID of req, read from its
+// DefaultSutureIDHeader header or, when missing, from its context. parent is returned as is when req has no Suture ID.
+// Reconcilers and webhooks can capture the ID of the incoming request once and pass the returned context to the
+// goroutines they spawn, so that all the requests issued from them carry the same ID:
+//
+//	ctx = ContextWithSutureIDFromRequest(ctx, req)
+//	go func() {
+//		// requests sent via a HeadersTransport using ctx carry the Suture ID of req
+//	}()
+func ContextWithSutureIDFromRequest(parent context.Context, req *http.Request) context.Context {
+	return ContextWithSutureIDFromRequestHeader(parent, req, DefaultSutureIDHeader)
+}
+
+// ContextWithSutureIDFromRequestHeader is like ContextWithSutureIDFromRequest, but it reads the Suture ID of req from
+// the given header, e.g. the one configured via WithSutureIDHeader.
+func ContextWithSutureIDFromRequestHeader(parent context.Context, req *http.Request, header string) context.Context {
+	if req == nil {
+		return parent
+	}
+	if id := req.Header.Get(header); id != "" {
+		return WithSutureID(parent, id)
+	}
+	if id, ok := SutureIDFromContext(req.Context()); ok && id != "" {
+		return WithSutureID(parent, id)
+	}
+	return parent
+}
+
+type templateValuesContextKey struct{}
+
+// ContextWithTemplateValue returns a copy of ctx carrying a value resolving the ${ctx:key} placeholders of the header
+// values when WithHeaderTemplating is enabled. Values set in parent contexts are preserved.
+func ContextWithTemplateValue(ctx context.Context, key, value string) context.Context {
+	values := maps.Clone(templateValuesFromContext(ctx))
+	if values == nil {
+		values = make(map[string]string, 1)
+	}
+	values[key] = value
+	return context.WithValue(ctx, templateValuesContextKey{}, values)
+}
+
+func templateValuesFromContext(ctx context.Context) map[string]string {
+	values, _ := ctx.Value(templateValuesContextKey{}).(map[string]string)
+	return values
+}
@@ -0,0 +1,280 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextWithFault(t *testing.T) {
+	errFault := errors.New("injected fault")
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantErr  error
+		minDelay time.Duration
+		wantSent bool
+	}{
+		{
+			name:     "no fault",
+			ctx:      context.Background(),
+			wantSent: true,
+		},
+		{
+			name:     "error",
+			ctx:      ContextWithFault(context.Background(), 0, errFault),
+			wantErr:  errFault,
+			wantSent: false,
+		},
+		{
+			name:     "delay",
+			ctx:      ContextWithFault(context.Background(), 50*time.Millisecond, nil),
+			minDelay: 50 * time.Millisecond,
+			wantSent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := false
+			transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				sent = true
+				return newTestResponse(req, http.StatusOK, ""), nil
+			}))
+
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+
+			start := time.Now()
+			_, err = transport.RoundTrip(req)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.GreaterOrEqual(t, time.Since(start), tt.minDelay)
+			assert.Equal(t, tt.wantSent, sent)
+		})
+	}
+}
+
+func TestContextWithInheritedHeaders(t *testing.T) {
+	var gotHeaders http.Header
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotHeaders = req.Header.Clone()
+		return newTestResponse(req, http.StatusOK, ""), nil
+	}))
+
+	parent := http.Header{}
+	parent.Set("X-Request-Id", "abc")
+	parent.Add("X-Forwarded-For", "10.0.0.1")
+	parent.Add("X-Forwarded-For", "10.0.0.2")
+	parent.Set("Authorization", "Bearer secret")
+	ctx := ContextWithInheritedHeaders(context.Background(), parent, "x-request-id", "X-Forwarded-For", "X-Missing")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "abc", gotHeaders.Get("X-Request-Id"))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, gotHeaders.Values("X-Forwarded-For"))
+	assert.Empty(t, gotHeaders.Get("Authorization"))
+	assert.NotContains(t, gotHeaders, "X-Missing")
+}
+
+func TestWithSutureID(t *testing.T) {
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		wantSutureID string
+	}{
+		{
+			name:         "context beats env",
+			ctx:          WithSutureID(context.Background(), "from-context"),
+			wantSutureID: "from-context",
+		},
+		{
+			name:         "env fallback",
+			ctx:          context.Background(),
+			wantSutureID: "from-env",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SUTURE_ID", "from-env")
+
+			var gotSutureID string
+			transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				gotSutureID = req.Header.Get(DefaultSutureIDHeader)
+				return newTestResponse(req, http.StatusOK, ""), nil
+			}))
+
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+			_, err = transport.RoundTrip(req)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.wantSutureID, gotSutureID)
+		})
+	}
+}
+
+func TestWithGeneratedFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		envSutureID  string
+		ctx          context.Context
+		wantSutureID string
+	}{
+		{
+			name: "generated",
+			ctx:  context.Background(),
+		},
+		{
+			name:         "env not overwritten",
+			envSutureID:  "from-env",
+			ctx:          context.Background(),
+			wantSutureID: "from-env",
+		},
+		{
+			name:         "context not overwritten",
+			ctx:          WithSutureID(context.Background(), "from-context"),
+			wantSutureID: "from-context",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SUTURE_ID", tt.envSutureID)
+
+			var gotSutureID string
+			transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				gotSutureID = req.Header.Get(DefaultSutureIDHeader)
+				return newTestResponse(req, http.StatusOK, ""), nil
+			}), WithGeneratedFallback())
+
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+			res, err := transport.RoundTrip(req)
+			assert.NoError(t, err)
+
+			if tt.wantSutureID != "" {
+				assert.Equal(t, tt.wantSutureID, gotSutureID)
+				return
+			}
+			id, err := uuid.Parse(gotSutureID)
+			assert.NoError(t, err)
+			assert.Equal(t, uuid.Version(4), id.Version())
+
+			resSutureID, ok := SutureIDFromContext(res.Request.Context())
+			assert.True(t, ok)
+			assert.Equal(t, gotSutureID, resSutureID)
+		})
+	}
+}
+
+func TestContextWithSutureIDFromRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    func() *http.Request
+		wantID string
+		wantOK bool
+	}{
+		{
+			name: "header",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+				req.Header.Set(DefaultSutureIDHeader, "header-id")
+				return req.WithContext(WithSutureID(req.Context(), "context-id"))
+			},
+			wantID: "header-id",
+			wantOK: true,
+		},
+		{
+			name: "context",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+				return req.WithContext(WithSutureID(req.Context(), "context-id"))
+			},
+			wantID: "context-id",
+			wantOK: true,
+		},
+		{
+			name: "none",
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			},
+		},
+		{
+			name: "nil request",
+			req: func() *http.Request {
+				return nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ContextWithSutureIDFromRequest(context.Background(), tt.req())
+			id, ok := SutureIDFromContext(ctx)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantID, id)
+		})
+	}
+}
+
+func TestContextWithSutureIDFromRequestHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("X-Suture-Id", "custom-id")
+
+	_, ok := SutureIDFromContext(ContextWithSutureIDFromRequest(context.Background(), req))
+	assert.False(t, ok)
+
+	id, ok := SutureIDFromContext(ContextWithSutureIDFromRequestHeader(context.Background(), req, "X-Suture-Id"))
+	assert.True(t, ok)
+	assert.Equal(t, "custom-id", id)
+}
+
+func TestContextWithSutureIDFromRequestConcurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get(DefaultSutureIDHeader)))
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: NewHeadersTransport(nil, WithStaticSutureID("env-id")),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("reconcile-%d", i)
+			incoming := httptest.NewRequest(http.MethodPost, "http://webhook", nil)
+			incoming.Header.Set(DefaultSutureIDHeader, id)
+			ctx := ContextWithSutureIDFromRequest(context.Background(), incoming)
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			if !assert.NoError(t, err) {
+				return
+			}
+			res, err := client.Do(req)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer res.Body.Close()
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, id, string(body))
+		}()
+	}
+	wg.Wait()
+}
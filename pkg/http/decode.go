@@ -0,0 +1,75 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxDecodeBodyBytes bounds the bodies read by DecodeJSON.
+const maxDecodeBodyBytes = 10 << 20
+
+// maxBodyPreviewBytes bounds the body preview included in decoding errors.
+const maxBodyPreviewBytes = 256
+
+// StatusError is returned by DecodeJSON when the response has a non-2xx status code.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d", e.StatusCode)
+}
+
+// DecodeJSON reads the response body, bounded to 10MiB, and unmarshals it into v, closing the body afterwards.
+// Non-2xx responses are not decoded and a *StatusError carrying the body is returned instead.
+// A nil v only checks the status code. Decoding errors include the request method and URL along with a bounded preview
+// of the body, redacted via RedactJSONBody. Bodies that are not valid JSON have the values of sensitive `key=value` and
+// `"key":` pairs masked instead.
+func DecodeJSON(res *http.Response, v interface{}) error {
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxDecodeBodyBytes+1))
+	if err != nil {
+		return fmt.Errorf("error reading body: %v", err)
+	}
+	if int64(len(body)) > maxDecodeBodyBytes {
+		return fmt.Errorf("body exceeds the maximum size of %d bytes", maxDecodeBodyBytes)
+	}
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &StatusError{
+			StatusCode: res.StatusCode,
+			Body:       body,
+		}
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("error decoding body%s: %v (%s)", requestContext(res.Request), err, bodyPreview(body))
+	}
+	return nil
+}
+
+func requestContext(req *http.Request) string {
+	if req == nil || req.URL == nil {
+		return ""
+	}
+	return fmt.Sprintf(" of %s %s", req.Method, req.URL.Redacted())
+}
+
+func bodyPreview(body []byte) string {
+	var redacted []byte
+	if json.Valid(body) {
+		redacted = RedactJSONBody(body)
+	} else {
+		redacted = redactTextBody(body)
+	}
+	if len(redacted) > maxBodyPreviewBytes {
+		redacted = append(redacted[:maxBodyPreviewBytes:maxBodyPreviewBytes], "..."...)
+	}
+	return fmt.Sprintf("body: %q", redacted)
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type object struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	tests := []struct {
+		name           string
+		statusCode     int
+		body           string
+		wantObject     object
+		wantStatusCode int
+		wantErr        bool
+	}{
+		{
+			name:       "ok",
+			statusCode: http.StatusOK,
+			body:       `{"name":"mariadb","count":3}`,
+			wantObject: object{Name: "mariadb", Count: 3},
+			wantErr:    false,
+		},
+		{
+			name:       "invalid JSON",
+			statusCode: http.StatusOK,
+			body:       `{"name":`,
+			wantErr:    true,
+		},
+		{
+			name:           "not found",
+			statusCode:     http.StatusNotFound,
+			body:           `{"error":"not found"}`,
+			wantStatusCode: http.StatusNotFound,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := newTestResponse(nil, tt.statusCode, tt.body)
+
+			var got object
+			err := DecodeJSON(res, &got)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantObject, got)
+				return
+			}
+			assert.Error(t, err)
+
+			var statusErr *StatusError
+			if tt.wantStatusCode != 0 {
+				assert.True(t, errors.As(err, &statusErr))
+				assert.Equal(t, tt.wantStatusCode, statusErr.StatusCode)
+				assert.Equal(t, tt.body, string(statusErr.Body))
+			} else {
+				assert.False(t, errors.As(err, &statusErr))
+			}
+		})
+	}
+}
+
+func TestDecodeJSONErrorContext(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantContain []string
+		wantExclude []string
+	}{
+		{
+			name: "invalid JSON",
+			body: `<html>Bad Gateway password=secret</html>`,
+			wantContain: []string{
+				"GET http://example.com/api/v1/backups",
+				"Bad Gateway password=" + redactedValue,
+			},
+			wantExclude: []string{"secret"},
+		},
+		{
+			name: "truncated JSON",
+			body: `{"name":"mariadb","rootPassword": "secret","token":"abc`,
+			wantContain: []string{
+				"GET http://example.com/api/v1/backups",
+				`\"name\":\"mariadb\"`,
+				`\"rootPassword\": ` + redactedValue,
+				`\"token\":` + redactedValue,
+			},
+			wantExclude: []string{"secret", "abc"},
+		},
+		{
+			name: "unexpected type",
+			body: `["mariadb",{"rootPassword":"secret"}]`,
+			wantContain: []string{
+				"GET http://example.com/api/v1/backups",
+				`mariadb`,
+				redactedValue,
+			},
+			wantExclude: []string{"secret"},
+		},
+		{
+			name: "long body",
+			body: `["` + strings.Repeat("a", 1024) + `","end"]`,
+			wantContain: []string{
+				"GET http://example.com/api/v1/backups",
+				`[\"` + strings.Repeat("a", maxBodyPreviewBytes-len(`["`)) + "...",
+			},
+			wantExclude: []string{"end"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/api/v1/backups", nil)
+			res := newTestResponse(req, http.StatusOK, tt.body)
+
+			var got map[string]interface{}
+			err := DecodeJSON(res, &got)
+			assert.Error(t, err)
+			for _, s := range tt.wantContain {
+				assert.Contains(t, err.Error(), s)
+			}
+			for _, s := range tt.wantExclude {
+				assert.NotContains(t, err.Error(), s)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// MergePolicy defines how a configured header is merged into the request headers.
+type MergePolicy int
+
+const (
+	// MergePolicySet replaces the existing values of the header. This is the default.
+	MergePolicySet MergePolicy = iota
+	// MergePolicyAdd appends the value to the existing values of the header.
+	MergePolicyAdd
+)
+
+// WithHeaderMergePolicy sets the merge policy of the configured headers, keyed by header name.
+// Headers not present in the map use MergePolicySet.
+func WithHeaderMergePolicy(policies map[string]MergePolicy) TransportOption {
+	return func(t *HeadersTransport) {
+		t.mergePolicies = make(map[string]MergePolicy, len(policies))
+		for name, policy := range policies {
+			t.mergePolicies[http.CanonicalHeaderKey(name)] = policy
+		}
+	}
+}
+
+// WithMultiHeaders sets headers sent with several values, e.g. repeated X-Forwarded-For entries. As the headers set via
+// WithHeaders, which are applied before them, they follow WithHeaderMergePolicy: by default their values replace the
+// ones already present in the request, whereas MergePolicyAdd appends them. Use WithHeaders for single-valued headers.
+func WithMultiHeaders(headers map[string][]string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.multiHeaders = make(http.Header, len(headers))
+		for name, values := range headers {
+			t.multiHeaders[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+		}
+	}
+}
+
+func (t *HeadersTransport) mergeHeader(header http.Header, name, value string) {
+	t.mergeHeaderValues(header, name, []string{value})
+}
+
+func (t *HeadersTransport) mergeHeaderValues(header http.Header, name string, values []string) {
+	name = http.CanonicalHeaderKey(name)
+	if t.mergePolicies[name] == MergePolicyAdd {
+		header[name] = append(header[name], values...)
+		return
+	}
+	header[name] = slices.Clone(values)
+}
+
+// WithHeaderFunc sets a provider of per-request headers, such as rotating bearer tokens or trace IDs, called on every
+// request. The provided headers are merged on top of the static ones. If the provider returns an error, or invalid
+// headers, the request is not sent and the error is returned.
+func WithHeaderFunc(fn func(*http.Request) (map[string]string, error)) TransportOption {
+	return func(t *HeadersTransport) {
+		t.headerFunc = fn
+	}
+}
+
+func (t *HeadersTransport) setHeaderFuncHeaders(req *http.Request) error {
+	if t.headerFunc == nil {
+		return nil
+	}
+	headers, err := t.headerFunc(req)
+	if err != nil {
+		return fmt.Errorf("error getting request headers: %w", err)
+	}
+	if err := validateHeaders(headers); err != nil {
+		return err
+	}
+	for k, v := range headers {
+		t.mergeHeader(req.Header, k, v)
+	}
+	return nil
+}
+
+// WithExcludePaths forwards the requests whose URL path matches any of the patterns untouched: neither the Suture ID,
+// the configured headers nor the default JSON content headers are set, e.g. for probes that reject unexpected headers.
+// Patterns containing glob metacharacters are matched via path.Match, the others as path prefixes. Matching is
+// case-sensitive and ignores the query string.
+func WithExcludePaths(patterns ...string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.excludePaths = patterns
+	}
+}
+
+func (t *HeadersTransport) isExcludedPath(req *http.Request) bool {
+	for _, pattern := range t.excludePaths {
+		if strings.ContainsAny(pattern, "*?[") {
+			if matched, _ := path.Match(pattern, req.URL.Path); matched {
+				return true
+			}
+		} else if strings.HasPrefix(req.URL.Path, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+var templatePlaceholder = regexp.MustCompile(`\$\{ctx:([A-Za-z0-9_.-]+)\}`)
+
+// WithHeaderTemplating resolves the ${ctx:key} placeholders of the configured header values, e.g. "${ctx:tenant}",
+// against the values set via ContextWithTemplateValue. Only the allowed keys are resolved. Unresolved placeholders are
+// left as is when keepUnresolved is set, and dropped otherwise, in which case headers ending up empty are not sent.
+func WithHeaderTemplating(keepUnresolved bool, allowedKeys ...string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.templateKeys = append([]string{}, allowedKeys...)
+		t.templateKeepUnresolved = keepUnresolved
+	}
+}
+
+func (t *HeadersTransport) mergeTemplatedHeader(req *http.Request, name, value string) {
+	if t.templateKeys != nil && strings.Contains(value, "${ctx:") {
+		values := templateValuesFromContext(req.Context())
+		value = templatePlaceholder.ReplaceAllStringFunc(value, func(placeholder string) string {
+			key := templatePlaceholder.FindStringSubmatch(placeholder)[1]
+			if resolved, ok := values[key]; ok && slices.Contains(t.templateKeys, key) {
+				return resolved
+			}
+			if t.templateKeepUnresolved {
+				return placeholder
+			}
+			return ""
+		})
+		if value == "" {
+			return
+		}
+	}
+	t.mergeHeader(req.Header, name, value)
+}
+
+// ValidateHeaderName returns an error when name is not a valid header field name, according to the token rule of RFC 7230.
+func ValidateHeaderName(name string) error {
+	if !httpguts.ValidHeaderFieldName(name) {
+		return fmt.Errorf("invalid header name '%s'", name)
+	}
+	return nil
+}
+
+func validateMultiHeaders(headers http.Header) error {
+	for name, values := range headers {
+		if err := ValidateHeaderName(name); err != nil {
+			return err
+		}
+		for _, value := range values {
+			if err := ValidateHeaderValue(name, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateHeaderValue returns an error when value is not a valid header field value, e.g. when it contains a newline.
+func ValidateHeaderValue(name, value string) error {
+	if !httpguts.ValidHeaderFieldValue(value) {
+		return fmt.Errorf("invalid value for header '%s'", name)
+	}
+	return nil
+}
+
+func validateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if err := ValidateHeaderName(name); err != nil {
+			return err
+		}
+		if err := ValidateHeaderValue(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DoWithHeaders sends req using client, attaching extra headers for this call only.
+// The headers are merged by HeadersTransport on top of its configured ones, overriding them on conflict, so client
+// must use a HeadersTransport for them to be sent.
+func DoWithHeaders(client *http.Client, req *http.Request, extra map[string]string) (*http.Response, error) {
+	if err := validateHeaders(extra); err != nil {
+		return nil, err
+	}
+	return client.Do(req.WithContext(contextWithHeaders(req.Context(), extra)))
+}
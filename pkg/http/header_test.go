@@ -0,0 +1,364 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateHeaderName(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			header:  "X-Operator-Version",
+			wantErr: false,
+		},
+		{
+			name:    "underscore",
+			header:  "Suture_ID",
+			wantErr: false,
+		},
+		{
+			name:    "space",
+			header:  "X Bad",
+			wantErr: true,
+		},
+		{
+			name:    "colon",
+			header:  "X-Bad:",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			header:  "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateHeaderName(tt.header)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateHeaderValue(t *testing.T) {
+	assert.NoError(t, ValidateHeaderValue("X-Environment", "staging"))
+	assert.NoError(t, ValidateHeaderValue("X-Environment", ""))
+	assert.Error(t, ValidateHeaderValue("X-Environment", "bad\nvalue"))
+	assert.Error(t, ValidateHeaderValue("X-Environment", "bad\rvalue"))
+}
+
+func TestHeadersTransportInvalidHeaders(t *testing.T) {
+	sent := false
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sent = true
+		return newTestResponse(req, http.StatusOK, ""), nil
+	}), WithHeaders(map[string]string{"X Bad": "value"}))
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+
+	_, err = transport.RoundTrip(req)
+	assert.Error(t, err)
+	assert.False(t, sent)
+	assert.Error(t, transport.(*HeadersTransport).Validate())
+}
+
+func TestNewHeadersTransportE(t *testing.T) {
+	transport, err := NewHeadersTransportE(nil, WithHeaders(map[string]string{"X Bad": "value"}))
+	assert.ErrorContains(t, err, "X Bad")
+	assert.Nil(t, transport)
+
+	transport, err = NewHeadersTransportE(nil, WithHeaders(map[string]string{"X-Good": "value"}))
+	assert.NoError(t, err)
+	assert.NoError(t, transport.Validate())
+}
+
+func TestDoWithHeaders(t *testing.T) {
+	var gotHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: NewHeadersTransport(nil, WithHeaders(map[string]string{
+			"X-Operator": "mariadb-operator",
+			"X-Tenant":   "default",
+		})),
+	}
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NoError(t, err)
+
+	res, err := DoWithHeaders(client, req, map[string]string{
+		"X-Tenant":  "tenant-a",
+		"X-Call-Id": "123",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+
+	assert.Equal(t, "mariadb-operator", gotHeaders.Get("X-Operator"))
+	assert.Equal(t, "tenant-a", gotHeaders.Get("X-Tenant"))
+	assert.Equal(t, "123", gotHeaders.Get("X-Call-Id"))
+
+	req, err = http.NewRequest(http.MethodGet, server.URL, nil)
+	assert.NoError(t, err)
+	res, err = client.Do(req)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+
+	assert.Equal(t, "default", gotHeaders.Get("X-Tenant"))
+	assert.Empty(t, gotHeaders.Get("X-Call-Id"))
+}
+
+func TestWithHeaderMergePolicy(t *testing.T) {
+	var gotHeaders http.Header
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotHeaders = req.Header.Clone()
+		return newTestResponse(req, http.StatusOK, ""), nil
+	}), WithHeaders(map[string]string{
+		"X-Forwarded-For": "10.0.0.2",
+		"X-Tenant":        "default",
+	}), WithHeaderMergePolicy(map[string]MergePolicy{
+		"x-forwarded-for": MergePolicyAdd,
+	}))
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	req.Header.Set("X-Tenant", "tenant-a")
+
+	_, err = transport.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, gotHeaders.Values("X-Forwarded-For"))
+	assert.Equal(t, []string{"default"}, gotHeaders.Values("X-Tenant"))
+}
+
+func TestWithHeaderFunc(t *testing.T) {
+	var gotHeaders []http.Header
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotHeaders = append(gotHeaders, req.Header.Clone())
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+
+	var calls int
+	transport := NewHeadersTransport(base, WithHeaders(map[string]string{
+		"Authorization": "Bearer static",
+		"X-Operator":    "mariadb-operator",
+	}), WithHeaderFunc(func(req *http.Request) (map[string]string, error) {
+		calls++
+		return map[string]string{
+			"Authorization": fmt.Sprintf("Bearer token-%d", calls),
+		}, nil
+	}))
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		assert.NoError(t, err)
+		_, err = transport.RoundTrip(req)
+		assert.NoError(t, err)
+	}
+	if assert.Len(t, gotHeaders, 2) {
+		assert.Equal(t, "Bearer token-1", gotHeaders[0].Get("Authorization"))
+		assert.Equal(t, "Bearer token-2", gotHeaders[1].Get("Authorization"))
+		assert.Equal(t, "mariadb-operator", gotHeaders[1].Get("X-Operator"))
+	}
+
+	gotHeaders = nil
+	transport = NewHeadersTransport(base, WithHeaderFunc(func(req *http.Request) (map[string]string, error) {
+		return nil, errors.New("token expired")
+	}))
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	assert.ErrorContains(t, err, "token expired")
+	assert.Empty(t, gotHeaders)
+}
+
+func TestWithExcludePaths(t *testing.T) {
+	t.Setenv("SUTURE_ID", "suture-123")
+
+	tests := []struct {
+		name         string
+		url          string
+		wantExcluded bool
+	}{
+		{
+			name:         "excluded prefix",
+			url:          "http://example.com/healthz?verbose=true",
+			wantExcluded: true,
+		},
+		{
+			name:         "excluded glob",
+			url:          "http://example.com/readyz/mariadb",
+			wantExcluded: true,
+		},
+		{
+			name:         "case-sensitive",
+			url:          "http://example.com/HEALTHZ",
+			wantExcluded: false,
+		},
+		{
+			name:         "not excluded",
+			url:          "http://example.com/apis/k8s.mariadb.com/v1alpha1/mariadbs",
+			wantExcluded: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeaders http.Header
+			transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				gotHeaders = req.Header.Clone()
+				return newTestResponse(req, http.StatusOK, ""), nil
+			}), WithHeaders(map[string]string{
+				"X-Operator": "mariadb-operator",
+			}), WithExcludePaths("/healthz", "/readyz/*"))
+
+			req, err := http.NewRequest(http.MethodPost, tt.url, strings.NewReader("{}"))
+			assert.NoError(t, err)
+			_, err = transport.RoundTrip(req)
+			assert.NoError(t, err)
+
+			if tt.wantExcluded {
+				assert.Empty(t, gotHeaders)
+				return
+			}
+			assert.Equal(t, "suture-123", gotHeaders.Get(DefaultSutureIDHeader))
+			assert.Equal(t, "mariadb-operator", gotHeaders.Get("X-Operator"))
+			assert.Equal(t, "application/json", gotHeaders.Get("Content-Type"))
+		})
+	}
+}
+
+func TestWithHeaderTemplating(t *testing.T) {
+	headers := map[string]string{
+		"X-Tenant":  "${ctx:tenant}",
+		"X-Scope":   "tenant=${ctx:tenant},namespace=${ctx:namespace}",
+		"X-Secret":  "${ctx:secret}",
+		"X-Literal": "static",
+	}
+	ctx := ContextWithTemplateValue(context.Background(), "tenant", "tenant-a")
+	ctx = ContextWithTemplateValue(ctx, "secret", "s3cr3t")
+
+	tests := []struct {
+		name           string
+		keepUnresolved bool
+		wantHeaders    map[string]string
+	}{
+		{
+			name: "drop unresolved",
+			wantHeaders: map[string]string{
+				"X-Tenant":  "tenant-a",
+				"X-Scope":   "tenant=tenant-a,namespace=",
+				"X-Secret":  "",
+				"X-Literal": "static",
+			},
+		},
+		{
+			name:           "keep unresolved",
+			keepUnresolved: true,
+			wantHeaders: map[string]string{
+				"X-Tenant":  "tenant-a",
+				"X-Scope":   "tenant=tenant-a,namespace=${ctx:namespace}",
+				"X-Secret":  "${ctx:secret}",
+				"X-Literal": "static",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeaders http.Header
+			transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				gotHeaders = req.Header.Clone()
+				return newTestResponse(req, http.StatusOK, ""), nil
+			}), WithHeaders(headers), WithHeaderTemplating(tt.keepUnresolved, "tenant", "namespace"))
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+			_, err = transport.RoundTrip(req)
+			assert.NoError(t, err)
+
+			for name, want := range tt.wantHeaders {
+				assert.Equal(t, want, gotHeaders.Get(name), name)
+			}
+			if !tt.keepUnresolved {
+				assert.NotContains(t, gotHeaders, "X-Secret")
+			}
+		})
+	}
+}
+
+func TestWithMultiHeaders(t *testing.T) {
+	var gotHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		policies map[string]MergePolicy
+		want     []string
+	}{
+		{
+			name: "set",
+			want: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name: "add",
+			policies: map[string]MergePolicy{
+				"X-Forwarded-For": MergePolicyAdd,
+			},
+			want: []string{"192.168.0.1", "10.0.0.1", "10.0.0.2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{
+				Transport: NewHeadersTransport(nil, WithHeaders(map[string]string{
+					"X-Tenant": "default",
+				}), WithMultiHeaders(map[string][]string{
+					"x-forwarded-for": {"10.0.0.1", "10.0.0.2"},
+				}), WithHeaderMergePolicy(tt.policies)),
+			}
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			assert.NoError(t, err)
+			req.Header.Set("X-Tenant", "tenant-a")
+			req.Header.Set("X-Forwarded-For", "192.168.0.1")
+
+			for i := 0; i < 2; i++ {
+				res, err := client.Do(req)
+				assert.NoError(t, err)
+				assert.NoError(t, res.Body.Close())
+
+				assert.Equal(t, tt.want, gotHeaders.Values("X-Forwarded-For"))
+				assert.Equal(t, []string{"default"}, gotHeaders.Values("X-Tenant"))
+			}
+		})
+	}
+}
+
+func TestWithMultiHeadersInvalidValue(t *testing.T) {
+	_, err := NewHeadersTransportE(nil, WithMultiHeaders(map[string][]string{
+		"X-Forwarded-For": {"10.0.0.1", "bad\nvalue"},
+	}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// HTTPClientOption configures the *http.Client built by NewHTTPClient.
+type HTTPClientOption func(*httpClientOpts)
+
+type httpClientOpts struct {
+	timeout          time.Duration
+	roundTripper     http.RoundTripper
+	wrappers         []func(http.RoundTripper) http.RoundTripper
+	transportOptions []TransportOption
+}
+
+// WithClientTimeout sets the timeout of the client, 10s by default. It bounds the whole exchange, including the retries
+// performed by a RetryTransport and reading the response body, so it should be longer than the per-request timeout of
+// a TimeoutTransport, if any: the shortest of both applies. A zero timeout disables it.
+func WithClientTimeout(timeout time.Duration) HTTPClientOption {
+	return func(opts *httpClientOpts) {
+		opts.timeout = timeout
+	}
+}
+
+// WithClientRoundTripper sets the base round tripper sending the requests, http.DefaultTransport by default.
+func WithClientRoundTripper(rt http.RoundTripper) HTTPClientOption {
+	return func(opts *httpClientOpts) {
+		opts.roundTripper = rt
+	}
+}
+
+// WithClientTransportWrapper wraps the base round tripper, e.g. with NewRetryTransport, NewTimeoutTransport or a
+// MetricsTransport. Wrappers are applied in order, and the HeadersTransport wraps all of them, so every attempt carries
+// the configured headers. Wrappers are applied after the options configuring the base transport, e.g. WithTCPKeepAlive,
+// and they can't be combined with WithSessionAffinity.
+func WithClientTransportWrapper(wrap func(http.RoundTripper) http.RoundTripper) HTTPClientOption {
+	return func(opts *httpClientOpts) {
+		opts.wrappers = append(opts.wrappers, wrap)
+	}
+}
+
+// WithClientTransportOptions sets the options of the HeadersTransport of the client.
+func WithClientTransportOptions(transportOpts ...TransportOption) HTTPClientOption {
+	return func(opts *httpClientOpts) {
+		opts.transportOptions = append(opts.transportOptions, transportOpts...)
+	}
+}
+
+// NewHTTPClient creates an *http.Client whose transport is a HeadersTransport, so the requests carry the Suture ID
+// and the configured headers. It returns an error when the configuration of the HeadersTransport is invalid.
+func NewHTTPClient(opts ...HTTPClientOption) (*http.Client, error) {
+	clientOpts := httpClientOpts{
+		timeout: defaultTimeout,
+	}
+	for _, setOpt := range opts {
+		setOpt(&clientOpts)
+	}
+
+	// Options configuring the base transport, e.g. WithTLSSessionCache, only apply to an *http.Transport, so they are
+	// applied before the wrappers are inserted between the HeadersTransport and the base transport.
+	transport, err := NewHeadersTransportE(clientOpts.roundTripper, clientOpts.transportOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if len(clientOpts.wrappers) > 0 {
+		if transport.sessionAffinity {
+			return nil, errors.New("session affinity can't be combined with transport wrappers")
+		}
+		rt := transport.roundTripper
+		for _, wrap := range clientOpts.wrappers {
+			rt = wrap(rt)
+		}
+		transport.roundTripper = rt
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   clientOpts.timeout,
+	}, nil
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	var (
+		gotSutureID string
+		attempts    int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSutureID = r.Header.Get(DefaultSutureIDHeader)
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(
+		WithClientTransportOptions(WithStaticSutureID("suture-id")),
+		WithClientTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return NewRetryTransport(rt, WithRetryBackoff(0, 0))
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultTimeout, client.Timeout)
+	_, ok := client.Transport.(*HeadersTransport)
+	assert.True(t, ok)
+
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, "suture-id", gotSutureID)
+}
+
+func TestWithClientTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	tests := []struct {
+		name string
+		opts []HTTPClientOption
+	}{
+		{
+			name: "client timeout",
+			opts: []HTTPClientOption{
+				WithClientTimeout(50 * time.Millisecond),
+			},
+		},
+		{
+			name: "client timeout with per-request timeout",
+			opts: []HTTPClientOption{
+				WithClientTimeout(time.Minute),
+				WithClientTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+					return NewTimeoutTransport(rt, 50*time.Millisecond)
+				}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewHTTPClient(tt.opts...)
+			assert.NoError(t, err)
+
+			start := time.Now()
+			_, err = client.Get(server.URL)
+			assert.Error(t, err)
+			var netErr net.Error
+			assert.True(t, errors.As(err, &netErr) && netErr.Timeout(), "expected timeout error, got: %v", err)
+			assert.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
+
+func TestNewHTTPClientInvalidConfiguration(t *testing.T) {
+	client, err := NewHTTPClient(WithClientTransportOptions(WithHeaders(map[string]string{"X Bad": "value"})))
+	assert.Error(t, err)
+	assert.Nil(t, client)
+}
+
+func TestNewHTTPClientBaseTransportOptionsWithWrappers(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+
+	var wrapped http.RoundTripper
+	registry := prometheus.NewRegistry()
+	client, err := NewHTTPClient(
+		WithClientRoundTripper(server.Client().Transport),
+		WithClientTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			wrapped = rt
+			return NewRetryTransport(rt)
+		}),
+		WithClientTransportOptions(WithTLSSessionCache(8), WithConnPoolMetrics(registry)),
+	)
+	assert.NoError(t, err)
+
+	base, ok := wrapped.(*http.Transport)
+	if assert.True(t, ok) {
+		assert.NotNil(t, base.TLSClientConfig.ClientSessionCache)
+	}
+
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+	transport := client.Transport.(*HeadersTransport)
+	assert.Equal(t, 1.0, testutil.ToFloat64(transport.connPool.idle.WithLabelValues(serverURL.Host)))
+}
+
+func TestNewHTTPClientSessionAffinityWithWrappers(t *testing.T) {
+	client, err := NewHTTPClient(
+		WithClientTransportWrapper(func(rt http.RoundTripper) http.RoundTripper {
+			return NewRetryTransport(rt)
+		}),
+		WithClientTransportOptions(WithSessionAffinity()),
+	)
+	assert.Error(t, err)
+	assert.Nil(t, client)
+}
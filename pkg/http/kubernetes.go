@@ -0,0 +1,19 @@
+package http
+
+import (
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RestConfigWithSutureID returns a copy of config with the Suture ID transport installed, leaving config untouched.
+// It is safe to call on a config that has already been wrapped, no other HeadersTransport is added in that case.
+func RestConfigWithSutureID(config *rest.Config) *rest.Config {
+	wrapped := rest.CopyConfig(config)
+	WrapRestConfigWithSutureID(wrapped)
+	return wrapped
+}
+
+// NewClientWithSutureID creates a controller-runtime client sending the Suture ID with every request.
+func NewClientWithSutureID(config *rest.Config, opts client.Options) (client.Client, error) {
+	return client.New(RestConfigWithSutureID(config), opts)
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestNewClientWithSutureID(t *testing.T) {
+	t.Setenv("SUTURE_ID", "suture-123")
+
+	var gotSutureIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSutureIDs = append(gotSutureIDs, r.Header.Get(DefaultSutureIDHeader))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(&corev1.ConfigMapList{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMapList",
+			},
+		})
+	}))
+	defer server.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	config := &rest.Config{Host: server.URL}
+	WrapRestConfigWithSutureID(config)
+
+	for _, config := range []*rest.Config{{Host: server.URL}, config} {
+		gotSutureIDs = nil
+		k8sClient, err := NewClientWithSutureID(config, client.Options{
+			Scheme: clientgoscheme.Scheme,
+			Mapper: mapper,
+		})
+		assert.NoError(t, err)
+
+		var configMaps corev1.ConfigMapList
+		assert.NoError(t, k8sClient.List(context.Background(), &configMaps, client.InNamespace("default")))
+		assert.Equal(t, []string{"suture-123"}, gotSutureIDs)
+	}
+}
+
+func TestRestConfigWithSutureID(t *testing.T) {
+	config := &rest.Config{}
+	wrapped := RestConfigWithSutureID(config)
+	assert.Nil(t, config.WrapTransport)
+	assert.NotNil(t, wrapped.WrapTransport)
+
+	rt := RestConfigWithSutureID(wrapped).WrapTransport(http.DefaultTransport)
+	headersTransport, ok := rt.(*HeadersTransport)
+	assert.True(t, ok)
+	assert.Equal(t, http.DefaultTransport, headersTransport.WrappedRoundTripper())
+}
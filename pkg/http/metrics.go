@@ -0,0 +1,94 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "mariadb_operator_http_client"
+
+// MetricsTransport records Prometheus metrics of outbound requests: request count, in-flight requests and latency,
+// labeled by method and status code class. It can wrap any round tripper, including a HeadersTransport.
+type MetricsTransport struct {
+	roundTripper http.RoundTripper
+	requests     *prometheus.CounterVec
+	inFlight     prometheus.Gauge
+	latency      *prometheus.HistogramVec
+}
+
+// NewMetricsTransport creates a MetricsTransport registering its metrics against registerer. If the metrics are already
+// registered, e.g. by another MetricsTransport, the registered ones are reused.
+func NewMetricsTransport(rt http.RoundTripper, registerer prometheus.Registerer) (*MetricsTransport, error) {
+	transport := &MetricsTransport{
+		roundTripper: rt,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "requests_total",
+			Help:      "Total number of outbound HTTP requests.",
+		}, []string{"method", "code"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "in_flight_requests",
+			Help:      "Number of outbound HTTP requests in flight.",
+		}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of outbound HTTP requests until the response headers are received.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+	}
+	if transport.roundTripper == nil {
+		transport.roundTripper = http.DefaultTransport
+	}
+	var err error
+	if transport.requests, err = registerCollector(registerer, transport.requests); err != nil {
+		return nil, err
+	}
+	if transport.inFlight, err = registerCollector(registerer, transport.inFlight); err != nil {
+		return nil, err
+	}
+	if transport.latency, err = registerCollector(registerer, transport.latency); err != nil {
+		return nil, err
+	}
+	return transport, nil
+}
+
+// registerCollector registers collector against registerer, returning the collector already registered, if any, in
+// place of collector.
+func registerCollector[T prometheus.Collector](registerer prometheus.Registerer, collector T) (T, error) {
+	if err := registerer.Register(collector); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(T); ok {
+				return existing, nil
+			}
+		}
+		var zero T
+		return zero, err
+	}
+	return collector, nil
+}
+
+// WrappedRoundTripper returns the wrapped round tripper, so the transport chain can be inspected.
+func (t *MetricsTransport) WrappedRoundTripper() http.RoundTripper {
+	return t.roundTripper
+}
+
+func (t *MetricsTransport) RoundTrip(req *http.Request) (res *http.Response, err error) {
+	t.inFlight.Inc()
+	start := time.Now()
+	defer func() {
+		t.inFlight.Dec()
+		code := "error"
+		if err == nil {
+			code = statusClass(res.StatusCode / 100)
+		}
+		t.requests.WithLabelValues(req.Method, code).Inc()
+		t.latency.WithLabelValues(req.Method, code).Observe(time.Since(start).Seconds())
+	}()
+	return t.roundTripper.RoundTrip(req)
+}
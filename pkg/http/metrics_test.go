@@ -0,0 +1,56 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsTransport(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		switch req.URL.Path {
+		case "/error":
+			return nil, errors.New("connection reset")
+		case "/unavailable":
+			return newTestResponse(req, http.StatusServiceUnavailable, ""), nil
+		default:
+			return newTestResponse(req, http.StatusOK, ""), nil
+		}
+	})
+	transport, err := NewMetricsTransport(NewHeadersTransport(rt), registry)
+	assert.NoError(t, err)
+
+	for _, path := range []string{"/", "/", "/unavailable", "/error"} {
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com"+path, nil))
+		if err == nil {
+			assert.NoError(t, res.Body.Close())
+		}
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(transport.requests.WithLabelValues(http.MethodGet, "2xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(transport.requests.WithLabelValues(http.MethodGet, "5xx")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(transport.requests.WithLabelValues(http.MethodGet, "error")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(transport.inFlight))
+	assert.Equal(t, 3, testutil.CollectAndCount(transport.latency))
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	assert.Len(t, families, 3)
+
+	other, err := NewMetricsTransport(rt, registry)
+	assert.NoError(t, err)
+	assert.Equal(t, transport.requests, other.requests)
+	assert.Equal(t, transport.inFlight, other.inFlight)
+	assert.Equal(t, transport.latency, other.latency)
+
+	res, err := other.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+	assert.Equal(t, 3.0, testutil.ToFloat64(transport.requests.WithLabelValues(http.MethodGet, "2xx")))
+}
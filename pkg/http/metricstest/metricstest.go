@@ -0,0 +1,103 @@
+// Package metricstest provides helpers to assert the metrics recorded by a MetricsTransport in isolation.
+package metricstest
+
+import (
+	"net/http"
+	"testing"
+
+	mdbhttp "github.com/mariadb-operator/mariadb-operator/v25/pkg/http"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	requestsMetric = "mariadb_operator_http_client_requests_total"
+	inFlightMetric = "mariadb_operator_http_client_in_flight_requests"
+	latencyMetric  = "mariadb_operator_http_client_request_duration_seconds"
+)
+
+// Transport is a MetricsTransport registered against its own registry, so its metrics are not shared with other tests.
+type Transport struct {
+	*mdbhttp.MetricsTransport
+	registry *prometheus.Registry
+}
+
+// NewTransport creates a MetricsTransport wrapping rt with an isolated registry, failing the test if it can't be created.
+func NewTransport(tb testing.TB, rt http.RoundTripper) *Transport {
+	tb.Helper()
+	registry := prometheus.NewRegistry()
+	transport, err := mdbhttp.NewMetricsTransport(rt, registry)
+	if err != nil {
+		tb.Fatalf("error creating metrics transport: %v", err)
+	}
+	return &Transport{
+		MetricsTransport: transport,
+		registry:         registry,
+	}
+}
+
+// Snapshot returns the current values of the transport metrics, failing the test if they can't be gathered.
+func (t *Transport) Snapshot(tb testing.TB) Snapshot {
+	tb.Helper()
+	families, err := t.registry.Gather()
+	if err != nil {
+		tb.Fatalf("error gathering metrics: %v", err)
+	}
+	snapshot := Snapshot{
+		requests:     make(map[labels]float64),
+		latencyCount: make(map[labels]uint64),
+	}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch family.GetName() {
+			case requestsMetric:
+				snapshot.requests[labelsOf(metric)] = metric.GetCounter().GetValue()
+			case inFlightMetric:
+				snapshot.inFlight = metric.GetGauge().GetValue()
+			case latencyMetric:
+				snapshot.latencyCount[labelsOf(metric)] = metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return snapshot
+}
+
+// Snapshot holds the values of the metrics of a transport at a given point in time.
+type Snapshot struct {
+	requests     map[labels]float64
+	inFlight     float64
+	latencyCount map[labels]uint64
+}
+
+// Requests returns the number of requests with the given method and status code class, e.g. "2xx" or "error".
+func (s Snapshot) Requests(method, code string) float64 {
+	return s.requests[labels{method: method, code: code}]
+}
+
+// InFlight returns the number of requests in flight.
+func (s Snapshot) InFlight() float64 {
+	return s.inFlight
+}
+
+// LatencyCount returns the number of latency observations with the given method and status code class.
+func (s Snapshot) LatencyCount(method, code string) uint64 {
+	return s.latencyCount[labels{method: method, code: code}]
+}
+
+type labels struct {
+	method string
+	code   string
+}
+
+func labelsOf(metric *dto.Metric) labels {
+	var l labels
+	for _, pair := range metric.GetLabel() {
+		switch pair.GetName() {
+		case "method":
+			l.method = pair.GetValue()
+		case "code":
+			l.code = pair.GetValue()
+		}
+	}
+	return l
+}
@@ -0,0 +1,52 @@
+package metricstest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newRoundTripper(statusCode int) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: statusCode,
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	})
+}
+
+func TestTransportIsolation(t *testing.T) {
+	healthy := NewTransport(t, newRoundTripper(http.StatusOK))
+	unhealthy := NewTransport(t, newRoundTripper(http.StatusBadGateway))
+
+	for i := 0; i < 3; i++ {
+		res, err := healthy.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+		assert.NoError(t, err)
+		assert.NoError(t, res.Body.Close())
+	}
+	res, err := unhealthy.RoundTrip(httptest.NewRequest(http.MethodPost, "http://example.com", nil))
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+
+	healthySnapshot := healthy.Snapshot(t)
+	assert.Equal(t, 3.0, healthySnapshot.Requests(http.MethodGet, "2xx"))
+	assert.Equal(t, 0.0, healthySnapshot.Requests(http.MethodPost, "5xx"))
+	assert.Equal(t, uint64(3), healthySnapshot.LatencyCount(http.MethodGet, "2xx"))
+	assert.Equal(t, 0.0, healthySnapshot.InFlight())
+
+	unhealthySnapshot := unhealthy.Snapshot(t)
+	assert.Equal(t, 0.0, unhealthySnapshot.Requests(http.MethodGet, "2xx"))
+	assert.Equal(t, 1.0, unhealthySnapshot.Requests(http.MethodPost, "5xx"))
+	assert.Equal(t, uint64(1), unhealthySnapshot.LatencyCount(http.MethodPost, "5xx"))
+}
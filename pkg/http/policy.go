@@ -0,0 +1,131 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+)
+
+// WithHTTPSOnly rejects plaintext HTTP requests, except the ones sent to the allowlisted hosts (e.g. localhost).
+// Hosts are matched against the request hostname, without port.
+func WithHTTPSOnly(allowHosts ...string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.httpsOnly = true
+		t.plaintextAllowHosts = allowHosts
+	}
+}
+
+func (t *HeadersTransport) checkScheme(req *http.Request) error {
+	if !t.httpsOnly || req.URL.Scheme != "http" {
+		return nil
+	}
+	host := req.URL.Hostname()
+	if slices.Contains(t.plaintextAllowHosts, host) {
+		return nil
+	}
+	return fmt.Errorf("plaintext HTTP request to host '%s' is not allowed", host)
+}
+
+// WithVerifyRequestIDEcho checks that the request ID sent in the given header matches the one echoed back by the
+// server in the same response header, returning an error on mismatch to detect tampering by proxies.
+// The check is skipped when the request carries no ID or the server does not echo it.
+func WithVerifyRequestIDEcho(headerName string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.requestIDEchoHeader = headerName
+	}
+}
+
+func (t *HeadersTransport) verifyRequestIDEcho(req *http.Request, res *http.Response) error {
+	if t.requestIDEchoHeader == "" {
+		return nil
+	}
+	sent := req.Header.Get(t.requestIDEchoHeader)
+	echoed := res.Header.Get(t.requestIDEchoHeader)
+	if sent == "" || echoed == "" || sent == echoed {
+		return nil
+	}
+	return fmt.Errorf("request ID mismatch in header '%s': sent '%s' but got '%s'", t.requestIDEchoHeader, sent, echoed)
+}
+
+// WithRequestFilter implements an arbitrary request policy, e.g. blocking writes during maintenance. Requests for which
+// allow returns false are not sent, and onDeny provides the response or error returned to the caller instead.
+// A nil onDeny makes denied requests fail with a generic error.
+func WithRequestFilter(allow func(*http.Request) bool, onDeny func(*http.Request) (*http.Response, error)) TransportOption {
+	return func(t *HeadersTransport) {
+		t.requestFilter = allow
+		t.onDeny = onDeny
+	}
+}
+
+func (t *HeadersTransport) filterRequest(req *http.Request) (bool, *http.Response, error) {
+	if t.requestFilter == nil || t.requestFilter(req) {
+		return true, nil, nil
+	}
+	if t.onDeny == nil {
+		return false, nil, fmt.Errorf("request %s %s denied by filter", req.Method, req.URL.String())
+	}
+	res, err := t.onDeny(req)
+	return false, res, err
+}
+
+// WithBodyPrefixValidation validates request bodies without buffering them: the first n bytes are peeked and passed to
+// validate, e.g. to check the magic bytes of a content type, and the full body is then streamed as is. Requests failing
+// validation are not sent. Bodies shorter than n bytes are validated as a whole.
+func WithBodyPrefixValidation(n int, validate func([]byte) error) TransportOption {
+	return func(t *HeadersTransport) {
+		t.bodyPrefixSize = n
+		t.bodyPrefixValidate = validate
+	}
+}
+
+func (t *HeadersTransport) validateBodyPrefix(req *http.Request) error {
+	if t.bodyPrefixValidate == nil || t.bodyPrefixSize <= 0 || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	prefix := make([]byte, t.bodyPrefixSize)
+	n, err := io.ReadFull(req.Body, prefix)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		req.Body.Close()
+		return fmt.Errorf("error reading request body prefix: %v", err)
+	}
+	prefix = prefix[:n]
+	if err := t.bodyPrefixValidate(prefix); err != nil {
+		req.Body.Close()
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	req.Body = &multiReadCloser{
+		Reader: io.MultiReader(bytes.NewReader(prefix), req.Body),
+		Closer: req.Body,
+	}
+	return nil
+}
+
+// defaultForwardHeaders are the inherited headers forwarded unless overridden via WithForwardHeaderAllowlist.
+var defaultForwardHeaders = []string{
+	"X-Request-Id",
+	"X-Correlation-Id",
+	"X-Forwarded-For",
+	"Traceparent",
+	"Tracestate",
+}
+
+// WithForwardHeaderAllowlist restricts the headers inherited via ContextWithInheritedHeaders that are forwarded,
+// dropping any other one, so credentials or cookies of a proxied request are never leaked by mistake.
+// By default, only request correlation and tracing headers are forwarded.
+func WithForwardHeaderAllowlist(names ...string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.forwardHeaderAllowlist = make([]string, len(names))
+		for i, name := range names {
+			t.forwardHeaderAllowlist[i] = http.CanonicalHeaderKey(name)
+		}
+	}
+}
+
+func (t *HeadersTransport) isForwardAllowed(name string) bool {
+	if t.forwardHeaderAllowlist == nil {
+		return slices.Contains(defaultForwardHeaders, name)
+	}
+	return slices.Contains(t.forwardHeaderAllowlist, name)
+}
@@ -0,0 +1,279 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHTTPSOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantErr bool
+	}{
+		{
+			name:    "https",
+			url:     "https://example.com/api",
+			wantErr: false,
+		},
+		{
+			name:    "http",
+			url:     "http://example.com/api",
+			wantErr: true,
+		},
+		{
+			name:    "http allowlisted",
+			url:     "http://localhost:8080/api",
+			wantErr: false,
+		},
+	}
+
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(req, http.StatusOK, ""), nil
+	}), WithHTTPSOnly("localhost"))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.url, nil)
+			assert.NoError(t, err)
+
+			_, err = transport.RoundTrip(req)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWithVerifyRequestIDEcho(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		echoedID  string
+		wantErr   bool
+	}{
+		{
+			name:      "match",
+			requestID: "abc",
+			echoedID:  "abc",
+			wantErr:   false,
+		},
+		{
+			name:      "mismatch",
+			requestID: "abc",
+			echoedID:  "xyz",
+			wantErr:   true,
+		},
+		{
+			name:      "absent echo",
+			requestID: "abc",
+			echoedID:  "",
+			wantErr:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				res := newTestResponse(req, http.StatusOK, "")
+				if tt.echoedID != "" {
+					res.Header.Set("X-Request-Id", tt.echoedID)
+				}
+				return res, nil
+			})
+			transport := NewHeadersTransport(base, WithVerifyRequestIDEcho("X-Request-Id"))
+
+			req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+			req.Header.Set("X-Request-Id", tt.requestID)
+
+			_, err = transport.RoundTrip(req)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWithRequestFilter(t *testing.T) {
+	allowReads := func(req *http.Request) bool {
+		return req.Method == http.MethodGet
+	}
+	tests := []struct {
+		name       string
+		method     string
+		onDeny     func(*http.Request) (*http.Response, error)
+		wantSent   bool
+		wantStatus int
+		wantErr    bool
+	}{
+		{
+			name:       "allowed",
+			method:     http.MethodGet,
+			wantSent:   true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "denied with custom response",
+			method: http.MethodDelete,
+			onDeny: func(req *http.Request) (*http.Response, error) {
+				return newTestResponse(req, http.StatusServiceUnavailable, "maintenance"), nil
+			},
+			wantSent:   false,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:     "denied without custom response",
+			method:   http.MethodDelete,
+			wantSent: false,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := false
+			transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				sent = true
+				return newTestResponse(req, http.StatusOK, ""), nil
+			}), WithRequestFilter(allowReads, tt.onDeny))
+
+			req, err := http.NewRequest(tt.method, "http://example.com", nil)
+			assert.NoError(t, err)
+
+			res, err := transport.RoundTrip(req)
+			assert.Equal(t, tt.wantSent, sent)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+		})
+	}
+}
+
+func TestWithBodyPrefixValidation(t *testing.T) {
+	gzipMagic := func(prefix []byte) error {
+		if !bytes.HasPrefix(prefix, []byte{0x1f, 0x8b}) {
+			return errors.New("body is not gzip")
+		}
+		return nil
+	}
+	tests := []struct {
+		name     string
+		body     string
+		wantSent bool
+		wantErr  bool
+	}{
+		{
+			name:     "valid prefix",
+			body:     "\x1f\x8bcompressed payload",
+			wantSent: true,
+		},
+		{
+			name:     "invalid prefix",
+			body:     `{"foo":"bar"}`,
+			wantSent: false,
+			wantErr:  true,
+		},
+		{
+			name:     "body shorter than prefix",
+			body:     "\x1f",
+			wantSent: false,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sentBody []byte
+			sent := false
+			transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				sent = true
+				body, err := io.ReadAll(req.Body)
+				assert.NoError(t, err)
+				sentBody = body
+				return newTestResponse(req, http.StatusOK, ""), nil
+			}), WithBodyPrefixValidation(4, gzipMagic))
+
+			req, err := http.NewRequest(http.MethodPost, "http://example.com", io.NopCloser(strings.NewReader(tt.body)))
+			assert.NoError(t, err)
+
+			_, err = transport.RoundTrip(req)
+			assert.Equal(t, tt.wantSent, sent)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(sentBody))
+		})
+	}
+}
+
+func TestWithForwardHeaderAllowlist(t *testing.T) {
+	parent := http.Header{}
+	parent.Set("X-Request-Id", "abc")
+	parent.Set("X-Tenant", "tenant-a")
+	parent.Set("Authorization", "Bearer secret")
+	parent.Set("Cookie", "session=secret")
+
+	tests := []struct {
+		name        string
+		opts        []TransportOption
+		wantHeaders map[string]string
+	}{
+		{
+			name: "default allowlist",
+			wantHeaders: map[string]string{
+				"X-Request-Id":  "abc",
+				"X-Tenant":      "",
+				"Authorization": "",
+				"Cookie":        "",
+			},
+		},
+		{
+			name: "custom allowlist",
+			opts: []TransportOption{WithForwardHeaderAllowlist("x-tenant")},
+			wantHeaders: map[string]string{
+				"X-Request-Id":  "",
+				"X-Tenant":      "tenant-a",
+				"Authorization": "",
+				"Cookie":        "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeaders http.Header
+			transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				gotHeaders = req.Header.Clone()
+				return newTestResponse(req, http.StatusOK, ""), nil
+			}), tt.opts...)
+
+			ctx := ContextWithInheritedHeaders(context.Background(), parent,
+				"X-Request-Id", "X-Tenant", "Authorization", "Cookie")
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+			_, err = transport.RoundTrip(req)
+			assert.NoError(t, err)
+
+			for name, want := range tt.wantHeaders {
+				assert.Equal(t, want, gotHeaders.Get(name), name)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitTransport paces requests with a token bucket, so bursts of requests, e.g. mass reconciles after a restart,
+// don't get the client throttled by the server. Requests wait for a token before being sent, and they are not sent if
+// their context is done while waiting.
+type RateLimitTransport struct {
+	roundTripper http.RoundTripper
+	limiter      *rate.Limiter
+}
+
+// NewRateLimitTransport creates a RateLimitTransport allowing limit requests per second, with bursts of up to burst requests.
+func NewRateLimitTransport(rt http.RoundTripper, limit rate.Limit, burst int) http.RoundTripper {
+	transport := &RateLimitTransport{
+		roundTripper: rt,
+		limiter:      rate.NewLimiter(limit, burst),
+	}
+	if transport.roundTripper == nil {
+		transport.roundTripper = http.DefaultTransport
+	}
+	return transport
+}
+
+// WrappedRoundTripper returns the wrapped round tripper, so the transport chain can be inspected.
+func (t *RateLimitTransport) WrappedRoundTripper() http.RoundTripper {
+	return t.roundTripper
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	return t.roundTripper.RoundTrip(req)
+}
@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimitTransport(t *testing.T) {
+	var sent int
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+	transport := NewRateLimitTransport(rt, rate.Every(50*time.Millisecond), 1)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+		assert.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 140*time.Millisecond)
+	assert.Equal(t, 4, sent)
+}
+
+func TestRateLimitTransportContextCanceled(t *testing.T) {
+	var sent int
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+	transport := NewRateLimitTransport(rt, rate.Every(time.Hour), 1)
+
+	_, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	_, err = transport.RoundTrip(req)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, sent)
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeyParts are the parts of JSON keys, compared in lowercase, whose values are masked by RedactJSONBody.
+var sensitiveKeyParts = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"apikey",
+	"api_key",
+	"authorization",
+	"credential",
+	"privatekey",
+	"private_key",
+}
+
+// sensitiveTextPattern matches `key=value`, `key: value` and `"key":"value"` pairs whose keys contain any of the
+// sensitiveKeyParts, capturing the key along with its separator.
+var sensitiveTextPattern = func() *regexp.Regexp {
+	parts := make([]string, len(sensitiveKeyParts))
+	for i, part := range sensitiveKeyParts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile(`(?i)("?[\w.-]*(?:` + strings.Join(parts, "|") + `)[\w.-]*"?\s*[=:]\s*)("[^"]*"?|[^\s&,;<"]+)`)
+}()
+
+// RedactJSONBody masks the values of sensitive keys, such as passwords or tokens, at any depth of a JSON body.
+// Numbers are kept as they are. Bodies that are not valid JSON are returned as is.
+func RedactJSONBody(body []byte) []byte {
+	if !json.Valid(body) {
+		return body
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return body
+	}
+	redacted, err := json.Marshal(redactJSONValue(v))
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+func redactJSONValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if isSensitiveKey(key) {
+				value[key] = redactedValue
+			} else {
+				value[key] = redactJSONValue(child)
+			}
+		}
+		return value
+	case []interface{}:
+		for i, child := range value {
+			value[i] = redactJSONValue(child)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactTextBody masks the values of sensitive keys in bodies that are not valid JSON, such as HTML error pages,
+// form-encoded or truncated JSON bodies.
+func redactTextBody(body []byte) []byte {
+	return sensitiveTextPattern.ReplaceAllFunc(body, func(match []byte) []byte {
+		key := sensitiveTextPattern.FindSubmatch(match)[1]
+		return append(append([]byte{}, key...), redactedValue...)
+	})
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// sutureIDLogPrefix is the number of leading characters of the Suture ID kept in logs, the rest is redacted.
+const sutureIDLogPrefix = 4
+
+// WithRequestLogger logs the method, host, path, status code and duration of every request at V(1), and failed requests
+// at the default level. The Suture ID is redacted, only its first characters are logged.
+func WithRequestLogger(logger logr.Logger) TransportOption {
+	return func(t *HeadersTransport) {
+		t.requestLogger = &logger
+	}
+}
+
+func (t *HeadersTransport) logRequest(req *http.Request, res *http.Response, err error, duration time.Duration) {
+	if t.requestLogger == nil {
+		return
+	}
+	kv := []interface{}{
+		"method", req.Method,
+		"host", req.URL.Host,
+		"path", req.URL.Path,
+		"duration", duration.String(),
+	}
+	if sutureID := req.Header.Get(t.sutureIDHeader); sutureID != "" {
+		kv = append(kv, "suture-id", redactSutureID(sutureID))
+	}
+	if err != nil {
+		t.requestLogger.Error(err, "Request failed", kv...)
+		return
+	}
+	t.requestLogger.V(1).Info("Request", append(kv, "status-code", res.StatusCode)...)
+}
+
+func redactSutureID(id string) string {
+	if len(id) <= sutureIDLogPrefix {
+		return "***"
+	}
+	return id[:sutureIDLogPrefix] + "***"
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRequestLogger(t *testing.T) {
+	const sutureID = "suture-0123456789"
+	t.Setenv("SUTURE_ID", sutureID)
+
+	var logs []string
+	logger := funcr.New(func(prefix, args string) {
+		logs = append(logs, args)
+	}, funcr.Options{Verbosity: 1})
+
+	errConnReset := errors.New("connection reset")
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Path == "/error" {
+			return nil, errConnReset
+		}
+		return newTestResponse(req, http.StatusCreated, ""), nil
+	}), WithRequestLogger(logger))
+
+	_, err := transport.RoundTrip(httptest.NewRequest(http.MethodPost, "http://example.com/api/v1/pods", nil))
+	assert.NoError(t, err)
+	_, err = transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com/error", nil))
+	assert.ErrorIs(t, err, errConnReset)
+
+	if assert.Len(t, logs, 2) {
+		assert.Contains(t, logs[0], `"method"="POST"`)
+		assert.Contains(t, logs[0], `"host"="example.com"`)
+		assert.Contains(t, logs[0], `"path"="/api/v1/pods"`)
+		assert.Contains(t, logs[0], `"status-code"=201`)
+		assert.Contains(t, logs[0], `"duration"=`)
+		assert.Contains(t, logs[0], `"suture-id"="sutu***"`)
+
+		assert.Contains(t, logs[1], `"msg"="Request failed"`)
+		assert.Contains(t, logs[1], `"error"="connection reset"`)
+		assert.Contains(t, logs[1], `"path"="/error"`)
+	}
+	for _, log := range logs {
+		assert.NotContains(t, log, sutureID)
+	}
+}
@@ -0,0 +1,281 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithCaptureTrailers reports the values of the named response trailers to sink.
+// Trailers are only available once the response body has been fully read, so sink is called when the body reaches EOF
+// or is closed, whichever happens first. Trailers not sent by the server are omitted from the map.
+func WithCaptureTrailers(sink func(map[string]string), names ...string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.trailerSink = sink
+		t.trailerNames = names
+	}
+}
+
+// WithStripResponseHeaders removes the named headers (e.g. Set-Cookie or Server) from responses before returning them.
+func WithStripResponseHeaders(names ...string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.stripResponseHeaders = names
+	}
+}
+
+// WithNormalizeResponseHeaders canonicalizes the keys of response headers, so they can be accessed directly in the
+// header map and not only via Header.Get. Values of keys differing only in casing are merged.
+func WithNormalizeResponseHeaders() TransportOption {
+	return func(t *HeadersTransport) {
+		t.normalizeResponseHeaders = true
+	}
+}
+
+// WithResponseLatency records the round-trip latency, measured until the response headers are received, into the
+// context of the response request, so callers can retrieve it via LatencyFromResponse.
+func WithResponseLatency() TransportOption {
+	return func(t *HeadersTransport) {
+		t.responseLatency = true
+	}
+}
+
+// WithStatusRemap overrides the status code of responses for which remap returns a nonzero code, e.g. to turn a 200
+// carrying an error body returned by a buggy gateway into a 500. remap may read the response body as long as it
+// restores it afterwards.
+func WithStatusRemap(remap func(*http.Response) int) TransportOption {
+	return func(t *HeadersTransport) {
+		t.statusRemap = remap
+	}
+}
+
+// ErrResponseReadTimeout is returned when reading a response body makes no progress within the timeout configured
+// via WithResponseReadTimeout.
+var ErrResponseReadTimeout = errors.New("response body read timed out")
+
+// WithResponseReadTimeout aborts the request when its response body makes no progress within timeout, either because the
+// server stalls or because the caller does not read it, so slow consumers can't hold connections hostage.
+// Subsequent reads return ErrResponseReadTimeout. Aborting relies on context cancellation, which *http.Transport honors.
+func WithResponseReadTimeout(timeout time.Duration) TransportOption {
+	return func(t *HeadersTransport) {
+		t.responseReadTimeout = timeout
+	}
+}
+
+// WithValidateContentLength makes reading a response body fail with an error wrapping io.ErrUnexpectedEOF when it ends
+// before the declared Content-Length, to detect truncated responses. Responses of unknown length, such as chunked
+// ones, are not validated.
+func WithValidateContentLength() TransportOption {
+	return func(t *HeadersTransport) {
+		t.validateContentLength = true
+	}
+}
+
+// WithBodyProgress reports the number of response body bytes read so far to cb every interval while the body is being
+// read, to surface slow transfers of large responses. cb is called from a separate goroutine so it never blocks reads,
+// and it is called one last time with the total once the body reaches EOF, fails or is closed.
+func WithBodyProgress(interval time.Duration, cb func(bytesRead int64)) TransportOption {
+	return func(t *HeadersTransport) {
+		t.bodyProgressInterval = interval
+		t.bodyProgressFn = cb
+	}
+}
+
+func (t *HeadersTransport) processResponse(res *http.Response) error {
+	if t.normalizeResponseHeaders {
+		normalizeHeader(res.Header)
+	}
+	for _, name := range t.stripResponseHeaders {
+		res.Header.Del(name)
+	}
+	if t.statusRemap != nil {
+		if code := t.statusRemap(res); code != 0 {
+			res.StatusCode = code
+			res.Status = fmt.Sprintf("%d %s", code, http.StatusText(code))
+		}
+	}
+	if t.validateContentLength && res.Body != nil && res.ContentLength > 0 &&
+		(res.Request == nil || res.Request.Method != http.MethodHead) {
+		res.Body = &contentLengthBody{
+			ReadCloser: res.Body,
+			declared:   res.ContentLength,
+		}
+	}
+	if t.bodyProgressFn != nil && t.bodyProgressInterval > 0 && res.Body != nil {
+		res.Body = newProgressBody(res.Body, t.bodyProgressInterval, t.bodyProgressFn)
+	}
+	if t.trailerSink != nil && res.Body != nil {
+		res.Body = &trailerCaptureBody{
+			ReadCloser: res.Body,
+			res:        res,
+			names:      t.trailerNames,
+			sink:       t.trailerSink,
+		}
+	}
+	return t.gzipResponse(res)
+}
+
+func normalizeHeader(header http.Header) {
+	for key, values := range header {
+		canonicalKey := http.CanonicalHeaderKey(key)
+		if canonicalKey == key {
+			continue
+		}
+		delete(header, key)
+		header[canonicalKey] = append(header[canonicalKey], values...)
+	}
+}
+
+type trailerCaptureBody struct {
+	io.ReadCloser
+	res   *http.Response
+	names []string
+	sink  func(map[string]string)
+	once  sync.Once
+}
+
+func (b *trailerCaptureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.capture()
+	}
+	return n, err
+}
+
+func (b *trailerCaptureBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.capture()
+	return err
+}
+
+func (b *trailerCaptureBody) capture() {
+	b.once.Do(func() {
+		trailers := make(map[string]string, len(b.names))
+		for _, name := range b.names {
+			if value := b.res.Trailer.Get(name); value != "" {
+				trailers[name] = value
+			}
+		}
+		b.sink(trailers)
+	})
+}
+
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+type readTimeoutBody struct {
+	io.ReadCloser
+	timeout  time.Duration
+	timer    *time.Timer
+	timedOut atomic.Bool
+	cancel   context.CancelFunc
+}
+
+func newReadTimeoutBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) *readTimeoutBody {
+	b := &readTimeoutBody{
+		ReadCloser: body,
+		timeout:    timeout,
+		cancel:     cancel,
+	}
+	b.timer = time.AfterFunc(timeout, func() {
+		b.timedOut.Store(true)
+		cancel()
+	})
+	return b
+}
+
+func (b *readTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if b.timedOut.Load() {
+		return n, ErrResponseReadTimeout
+	}
+	if err != nil {
+		b.timer.Stop()
+	} else {
+		b.timer.Reset(b.timeout)
+	}
+	return n, err
+}
+
+func (b *readTimeoutBody) Close() error {
+	b.timer.Stop()
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+type contentLengthBody struct {
+	io.ReadCloser
+	declared int64
+	read     int64
+}
+
+func (b *contentLengthBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if err == io.EOF && b.read < b.declared {
+		return n, fmt.Errorf("%w: read %d bytes out of %d declared in Content-Length", io.ErrUnexpectedEOF, b.read, b.declared)
+	}
+	return n, err
+}
+
+type progressBody struct {
+	io.ReadCloser
+	read     atomic.Int64
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newProgressBody(body io.ReadCloser, interval time.Duration, cb func(int64)) *progressBody {
+	b := &progressBody{
+		ReadCloser: body,
+		done:       make(chan struct{}),
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cb(b.read.Load())
+			case <-b.done:
+				cb(b.read.Load())
+				return
+			}
+		}
+	}()
+	return b
+}
+
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read.Add(int64(n))
+	if err != nil {
+		b.stop()
+	}
+	return n, err
+}
+
+func (b *progressBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.stop()
+	return err
+}
+
+func (b *progressBody) stop() {
+	b.stopOnce.Do(func() {
+		close(b.done)
+	})
+}
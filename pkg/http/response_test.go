@@ -0,0 +1,327 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCaptureTrailers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Checksum, X-Status")
+		_, _ = w.Write([]byte("body"))
+		w.Header().Set("X-Checksum", "abc123")
+		w.Header().Set("X-Status", "done")
+	}))
+	defer server.Close()
+
+	var captured map[string]string
+	calls := 0
+	client := &http.Client{
+		Transport: NewHeadersTransport(nil, WithCaptureTrailers(func(trailers map[string]string) {
+			captured = trailers
+			calls++
+		}, "X-Checksum", "X-Missing")),
+	}
+
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.Nil(t, captured)
+
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "body", string(body))
+	assert.NoError(t, res.Body.Close())
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, map[string]string{"X-Checksum": "abc123"}, captured)
+}
+
+func TestWithStripResponseHeaders(t *testing.T) {
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		res := newTestResponse(req, http.StatusOK, "")
+		res.Header.Set("Set-Cookie", "session=secret")
+		res.Header.Set("Server", "nginx")
+		res.Header.Set("Content-Type", "application/json")
+		return res, nil
+	})
+	transport := NewHeadersTransport(base, WithStripResponseHeaders("Set-Cookie", "server"))
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+
+	res, err := transport.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Empty(t, res.Header.Get("Set-Cookie"))
+	assert.Empty(t, res.Header.Get("Server"))
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+}
+
+func TestWithResponseLatency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		opts        []TransportOption
+		wantLatency bool
+	}{
+		{
+			name:        "enabled",
+			opts:        []TransportOption{WithResponseLatency()},
+			wantLatency: true,
+		},
+		{
+			name:        "disabled",
+			opts:        nil,
+			wantLatency: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{
+				Transport: NewHeadersTransport(nil, tt.opts...),
+			}
+			res, err := client.Get(server.URL)
+			assert.NoError(t, err)
+			defer res.Body.Close()
+
+			latency, ok := LatencyFromResponse(res)
+			assert.Equal(t, tt.wantLatency, ok)
+			if tt.wantLatency {
+				assert.GreaterOrEqual(t, latency, 50*time.Millisecond)
+				assert.Less(t, latency, time.Second)
+			}
+		})
+	}
+}
+
+func TestWithStatusRemap(t *testing.T) {
+	remap := func(res *http.Response) int {
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return 0
+		}
+		res.Body = io.NopCloser(bytes.NewReader(body))
+		if res.StatusCode == http.StatusOK && strings.Contains(string(body), `"error"`) {
+			return http.StatusInternalServerError
+		}
+		return 0
+	}
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "error body",
+			body:       `{"error":"backend unavailable"}`,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "valid body",
+			body:       `{"status":"ok"}`,
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return newTestResponse(req, http.StatusOK, tt.body), nil
+			})
+			transport := NewHeadersTransport(base, WithStatusRemap(remap))
+
+			req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+
+			res, err := transport.RoundTrip(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.True(t, strings.HasPrefix(res.Status, strconv.Itoa(tt.wantStatus)))
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
+
+func TestWithResponseReadTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+			return
+		}
+		_, _ = w.Write([]byte("second"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: NewHeadersTransport(nil, WithResponseReadTimeout(50*time.Millisecond)),
+	}
+
+	t.Run("slow server", func(t *testing.T) {
+		res, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		start := time.Now()
+		body, err := io.ReadAll(res.Body)
+		assert.ErrorIs(t, err, ErrResponseReadTimeout)
+		assert.Equal(t, "first", string(body))
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("slow consumer", func(t *testing.T) {
+		res, err := client.Get(server.URL)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		time.Sleep(100 * time.Millisecond)
+		_, err = io.ReadAll(res.Body)
+		assert.ErrorIs(t, err, ErrResponseReadTimeout)
+	})
+}
+
+func TestWithValidateContentLength(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		contentLength int64
+		wantErr       bool
+	}{
+		{
+			name:          "complete",
+			body:          "0123456789",
+			contentLength: 10,
+			wantErr:       false,
+		},
+		{
+			name:          "truncated",
+			body:          "01234",
+			contentLength: 10,
+			wantErr:       true,
+		},
+		{
+			name:          "unknown length",
+			body:          "01234",
+			contentLength: -1,
+			wantErr:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				res := newTestResponse(req, http.StatusOK, tt.body)
+				res.ContentLength = tt.contentLength
+				return res, nil
+			})
+			transport := NewHeadersTransport(base, WithValidateContentLength())
+
+			req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+
+			res, err := transport.RoundTrip(req)
+			assert.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			assert.Equal(t, tt.body, string(body))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWithBodyProgress(t *testing.T) {
+	const size = 1 << 20
+	var (
+		mux      sync.Mutex
+		progress []int64
+		done     = make(chan struct{})
+	)
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(req, http.StatusOK, strings.Repeat("a", size)), nil
+	}), WithBodyProgress(time.Millisecond, func(bytesRead int64) {
+		mux.Lock()
+		defer mux.Unlock()
+		progress = append(progress, bytesRead)
+		if bytesRead == size {
+			select {
+			case <-done:
+			default:
+				close(done)
+			}
+		}
+	}))
+
+	res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+	assert.NoError(t, err)
+
+	buf := make([]byte, 64<<10)
+	var read int
+	for {
+		n, err := res.Body.Read(buf)
+		read += n
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+	}
+	assert.NoError(t, res.Body.Close())
+	assert.Equal(t, size, read)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("final progress callback not called")
+	}
+	mux.Lock()
+	defer mux.Unlock()
+	assert.Greater(t, len(progress), 1)
+	assert.True(t, slices.IsSorted(progress))
+	assert.Equal(t, int64(size), progress[len(progress)-1])
+}
+
+func TestWithNormalizeResponseHeaders(t *testing.T) {
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		res := newTestResponse(req, http.StatusOK, "")
+		res.Header = http.Header{
+			"content-type": {"application/json"},
+			"x-request-id": {"abc"},
+			"X-Request-Id": {"def"},
+			"Server":       {"nginx"},
+		}
+		return res, nil
+	}), WithNormalizeResponseHeaders())
+
+	res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"application/json"}, res.Header["Content-Type"])
+	assert.ElementsMatch(t, []string{"abc", "def"}, res.Header["X-Request-Id"])
+	assert.Equal(t, []string{"nginx"}, res.Header["Server"])
+	assert.NotContains(t, res.Header, "content-type")
+	assert.NotContains(t, res.Header, "x-request-id")
+}
@@ -0,0 +1,31 @@
+package http
+
+import "net/http"
+
+// WithResponseIDHeader sets the response header carrying the server-side request ID, e.g. X-Request-Id, reported to the
+// WithOnResponse callback.
+func WithResponseIDHeader(name string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.responseIDHeader = name
+	}
+}
+
+// WithOnResponse sets a callback invoked for every response, including error ones, with the Suture ID sent with the
+// request, the server-side request ID read from the WithResponseIDHeader header, and the response status code.
+// IDs are empty when not available, and the callback is not invoked when the request fails without a response.
+func WithOnResponse(fn func(reqSutureID, respID string, status int)) TransportOption {
+	return func(t *HeadersTransport) {
+		t.onResponse = fn
+	}
+}
+
+func (t *HeadersTransport) notifyResponse(req *http.Request, res *http.Response) {
+	if t.onResponse == nil {
+		return
+	}
+	var respID string
+	if t.responseIDHeader != "" {
+		respID = res.Header.Get(t.responseIDHeader)
+	}
+	t.onResponse(req.Header.Get(t.sutureIDHeader), respID, res.StatusCode)
+}
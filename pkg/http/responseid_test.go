@@ -0,0 +1,76 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithOnResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		status       int
+		wantRespID   string
+		wantSutureID string
+	}{
+		{
+			name:         "success",
+			header:       "X-Request-Id",
+			status:       http.StatusOK,
+			wantRespID:   "server-id",
+			wantSutureID: "suture-id",
+		},
+		{
+			name:         "error status",
+			header:       "X-Request-Id",
+			status:       http.StatusInternalServerError,
+			wantRespID:   "server-id",
+			wantSutureID: "suture-id",
+		},
+		{
+			name:         "no response ID header",
+			status:       http.StatusNotFound,
+			wantSutureID: "suture-id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Request-Id", "server-id")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			var (
+				calls     int
+				gotSuture string
+				gotRespID string
+				gotStatus int
+			)
+			client := &http.Client{
+				Transport: NewHeadersTransport(nil,
+					WithStaticSutureID("suture-id"),
+					WithResponseIDHeader(tt.header),
+					WithOnResponse(func(reqSutureID, respID string, status int) {
+						calls++
+						gotSuture = reqSutureID
+						gotRespID = respID
+						gotStatus = status
+					}),
+				),
+			}
+			res, err := client.Get(server.URL)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+
+			assert.Equal(t, 1, calls)
+			assert.Equal(t, tt.wantSutureID, gotSuture)
+			assert.Equal(t, tt.wantRespID, gotRespID)
+			assert.Equal(t, tt.status, gotStatus)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryBaseBackoff = 100 * time.Millisecond
+	defaultRetryMaxBackoff  = 2 * time.Second
+)
+
+// RetryOption configures a RetryTransport.
+type RetryOption func(*RetryTransport)
+
+// RetryTransport retries requests failing with transient errors, such as connection resets or gateway errors returned
+// while the API server is rolling, see isRetryableError. Only idempotent requests are retried: GET and HEAD requests, or requests whose body
+// can be rewound via GetBody.
+type RetryTransport struct {
+	roundTripper      http.RoundTripper
+	maxAttempts       int
+	baseBackoff       time.Duration
+	maxBackoff        time.Duration
+	retryableStatus   map[int]bool
+	perAttemptTimeout time.Duration
+	retryStorm        *retryStorm
+}
+
+func NewRetryTransport(rt http.RoundTripper, opts ...RetryOption) http.RoundTripper {
+	transport := &RetryTransport{
+		roundTripper: rt,
+		maxAttempts:  defaultRetryMaxAttempts,
+		baseBackoff:  defaultRetryBaseBackoff,
+		maxBackoff:   defaultRetryMaxBackoff,
+		retryableStatus: map[int]bool{
+			http.StatusBadGateway:         true,
+			http.StatusServiceUnavailable: true,
+			http.StatusGatewayTimeout:     true,
+		},
+	}
+	if transport.roundTripper == nil {
+		transport.roundTripper = http.DefaultTransport
+	}
+	for _, setOpt := range opts {
+		setOpt(transport)
+	}
+	return transport
+}
+
+// WithRetryMaxAttempts sets the maximum number of attempts per request, including the first one, 3 by default.
+func WithRetryMaxAttempts(n int) RetryOption {
+	return func(t *RetryTransport) {
+		if n > 0 {
+			t.maxAttempts = n
+		}
+	}
+}
+
+// WithRetryBackoff sets the exponential backoff between attempts: the delay doubles from base up to max, with full jitter.
+func WithRetryBackoff(base, max time.Duration) RetryOption {
+	return func(t *RetryTransport) {
+		t.baseBackoff = base
+		t.maxBackoff = max
+	}
+}
+
+// WithRetryableStatusCodes sets the response status codes triggering a retry, 502, 503 and 504 by default.
+func WithRetryableStatusCodes(codes ...int) RetryOption {
+	return func(t *RetryTransport) {
+		t.retryableStatus = make(map[int]bool, len(codes))
+		for _, code := range codes {
+			t.retryableStatus[code] = true
+		}
+	}
+}
+
+// WithPerAttemptTimeout bounds the duration of each attempt until the response headers are received, so a single slow
+// attempt doesn't consume the whole budget of the request. Reading the response body, which can't be retried, is not
+// bounded by it. Attempts timing out are retried as long as the request context, which bounds the overall duration,
+// e.g. via ContextWithTimeout, is not done.
+func WithPerAttemptTimeout(timeout time.Duration) RetryOption {
+	return func(t *RetryTransport) {
+		t.perAttemptTimeout = timeout
+	}
+}
+
+// WrappedRoundTripper returns the wrapped round tripper, so the transport chain can be inspected.
+func (t *RetryTransport) WrappedRoundTripper() http.RoundTripper {
+	return t.roundTripper
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.retryStorm != nil {
+		t.retryStorm.recordRequest()
+	}
+	if !isRetryable(req) {
+		return t.roundTripper.RoundTrip(req)
+	}
+	for attempt := 1; ; attempt++ {
+		attemptReq, err := rewindRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+		res, err := t.roundTripAttempt(attemptReq)
+		if attempt >= t.maxAttempts || req.Context().Err() != nil {
+			return res, err
+		}
+		if err == nil && !t.retryableStatus[res.StatusCode] {
+			return res, nil
+		}
+		if err != nil && !isRetryableError(err) {
+			return nil, err
+		}
+		if t.retryStorm != nil && !t.retryStorm.allowRetry() {
+			return res, err
+		}
+		if err == nil {
+			drainBody(res.Body)
+		}
+
+		timer := time.NewTimer(t.backoff(attempt))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *RetryTransport) roundTripAttempt(req *http.Request) (*http.Response, error) {
+	if t.perAttemptTimeout <= 0 {
+		return t.roundTripper.RoundTrip(req)
+	}
+	// the timer is disarmed once the response headers are received, whereas the context is canceled when the body is closed
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(t.perAttemptTimeout, cancel)
+	res, err := t.roundTripper.RoundTrip(req.WithContext(ctx))
+	timedOut := !timer.Stop()
+	if err != nil || timedOut {
+		cancel()
+		if res != nil {
+			res.Body.Close()
+		}
+		if timedOut && req.Context().Err() == nil {
+			return nil, fmt.Errorf("attempt timed out after %v: %w", t.perAttemptTimeout, context.DeadlineExceeded)
+		}
+		return nil, err
+	}
+	res.Body = &cancelOnCloseBody{
+		ReadCloser: res.Body,
+		cancel:     cancel,
+	}
+	return res, nil
+}
+
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	backoff := t.baseBackoff
+	for i := 1; i < attempt && backoff < t.maxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, t.maxBackoff)
+	if backoff <= 0 {
+		return 0
+	}
+	return rand.N(backoff + 1)
+}
+
+func isRetryable(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	}
+	return req.GetBody != nil
+}
+
+// isRetryableError returns whether err is a transient connection error, such as a connection reset or refused while
+// the server is restarting, or a timeout. Permanent errors, e.g. TLS verification failures, are not retried.
+func isRetryableError(err error) bool {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func rewindRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 1 || req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	rewound := req.Clone(req.Context())
+	rewound.Body = body
+	return rewound, nil
+}
+
+// drainBody reads a bounded amount of a discarded response body before closing it, so the connection can be reused.
+func drainBody(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 4096))
+	body.Close()
+}
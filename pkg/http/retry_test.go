@@ -0,0 +1,293 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int32
+		opts        []RetryOption
+		newRequest  func(url string) *http.Request
+		wantStatus  int
+		wantAttempt int32
+	}{
+		{
+			name:     "GET succeeds after failures",
+			failures: 2,
+			newRequest: func(url string) *http.Request {
+				req, _ := http.NewRequest(http.MethodGet, url, nil)
+				return req
+			},
+			wantStatus:  http.StatusOK,
+			wantAttempt: 3,
+		},
+		{
+			name:     "GET gives up after max attempts",
+			failures: 5,
+			opts:     []RetryOption{WithRetryMaxAttempts(2)},
+			newRequest: func(url string) *http.Request {
+				req, _ := http.NewRequest(http.MethodGet, url, nil)
+				return req
+			},
+			wantStatus:  http.StatusBadGateway,
+			wantAttempt: 2,
+		},
+		{
+			name:     "non retryable status",
+			failures: 1,
+			opts:     []RetryOption{WithRetryableStatusCodes(http.StatusServiceUnavailable)},
+			newRequest: func(url string) *http.Request {
+				req, _ := http.NewRequest(http.MethodGet, url, nil)
+				return req
+			},
+			wantStatus:  http.StatusBadGateway,
+			wantAttempt: 1,
+		},
+		{
+			name:     "POST with GetBody is retried",
+			failures: 1,
+			newRequest: func(url string) *http.Request {
+				req, _ := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(`{"foo":"bar"}`))
+				return req
+			},
+			wantStatus:  http.StatusOK,
+			wantAttempt: 2,
+		},
+		{
+			name:     "POST without GetBody is not retried",
+			failures: 1,
+			newRequest: func(url string) *http.Request {
+				req, _ := http.NewRequest(http.MethodPost, url, io.NopCloser(bytes.NewBufferString(`{"foo":"bar"}`)))
+				return req
+			},
+			wantStatus:  http.StatusBadGateway,
+			wantAttempt: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if r.Method == http.MethodPost {
+					assert.Equal(t, `{"foo":"bar"}`, string(body))
+				}
+				if attempts.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusBadGateway)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			opts := append([]RetryOption{WithRetryBackoff(time.Millisecond, 5*time.Millisecond)}, tt.opts...)
+			client := &http.Client{Transport: NewRetryTransport(nil, opts...)}
+
+			res, err := client.Do(tt.newRequest(server.URL))
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+			assert.Equal(t, tt.wantStatus, res.StatusCode)
+			assert.Equal(t, tt.wantAttempt, attempts.Load())
+		})
+	}
+}
+
+func TestRetryTransportConnectionError(t *testing.T) {
+	var attempts atomic.Int32
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if attempts.Add(1) < 3 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+	transport := NewRetryTransport(rt, WithRetryBackoff(time.Millisecond, time.Millisecond))
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	res, err := transport.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestRetryTransportPermanentError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var dials atomic.Int32
+	base := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials.Add(1)
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}
+	client := &http.Client{
+		Transport: NewRetryTransport(base, WithRetryBackoff(time.Millisecond, time.Millisecond)),
+	}
+
+	_, err := client.Get(server.URL)
+	var certErr *tls.CertificateVerificationError
+	assert.ErrorAs(t, err, &certErr)
+	assert.Equal(t, int32(1), dials.Load())
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "connection reset",
+			err:  &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+			want: true,
+		},
+		{
+			name: "connection refused",
+			err:  &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			want: true,
+		},
+		{
+			name: "unexpected EOF",
+			err:  io.ErrUnexpectedEOF,
+			want: true,
+		},
+		{
+			name: "timeout",
+			err:  fmt.Errorf("attempt timed out: %w", context.DeadlineExceeded),
+			want: true,
+		},
+		{
+			name: "circuit open",
+			err:  fmt.Errorf("%w for host %q", ErrCircuitOpen, "example.com"),
+			want: false,
+		},
+		{
+			name: "invalid URL",
+			err:  errors.New("unsupported protocol scheme"),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isRetryableError(tt.err))
+		})
+	}
+}
+
+func TestWithRetryStormGuard(t *testing.T) {
+	var logs []string
+	logger := funcr.New(func(prefix, args string) {
+		logs = append(logs, args)
+	}, funcr.Options{})
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "retry_ratio",
+	})
+
+	var attempts int
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return newTestResponse(req, http.StatusBadGateway, ""), nil
+	})
+	transport := NewRetryTransport(rt,
+		WithRetryBackoff(0, 0),
+		WithRetryStormGuard(0.5, time.Minute, logger),
+		WithRetryRatioGauge(gauge),
+	)
+	doRequest := func() int {
+		attempts = 0
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+		assert.NoError(t, err)
+		assert.NoError(t, res.Body.Close())
+		return attempts
+	}
+
+	for i := 0; i < minRetryStormRequests-1; i++ {
+		assert.Equal(t, defaultRetryMaxAttempts, doRequest())
+	}
+	assert.Equal(t, 2.0, testutil.ToFloat64(gauge))
+
+	assert.Equal(t, 1, doRequest())
+	assert.Equal(t, 1, doRequest())
+	assert.InDelta(t, 18.0/11.0, testutil.ToFloat64(gauge), 0.001)
+
+	if assert.Len(t, logs, 1) {
+		assert.Contains(t, logs[0], "Retry storm detected")
+	}
+}
+
+func TestWithPerAttemptTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if attempts.Add(1) == 1 {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return newTestResponse(req, http.StatusOK, "ok"), nil
+	})
+	transport := NewRetryTransport(rt,
+		WithRetryBackoff(time.Millisecond, time.Millisecond),
+		WithPerAttemptTimeout(50*time.Millisecond),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+
+	start := time.Now()
+	res, err := transport.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), attempts.Load())
+
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.NoError(t, res.Body.Close())
+}
+
+func TestWithPerAttemptTimeoutSlowBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < 5; i++ {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(30 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: NewRetryTransport(nil, WithPerAttemptTimeout(50*time.Millisecond)),
+	}
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("chunk", 5), string(body))
+	assert.NoError(t, res.Body.Close())
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultRetryStormWindow = time.Minute
+	// minRetryStormRequests is the number of requests needed within a window before the guard may disable retries,
+	// so a few failures right after the window starts can't trip it.
+	minRetryStormRequests = 10
+)
+
+// WithRetryStormGuard disables retries for window when the ratio of retries to requests within window exceeds
+// maxRatio, like a circuit breaker for retries, so retries don't amplify the load of an already struggling server.
+// A warning is logged when retries get disabled.
+func WithRetryStormGuard(maxRatio float64, window time.Duration, logger logr.Logger) RetryOption {
+	return func(t *RetryTransport) {
+		storm := t.ensureRetryStorm()
+		storm.maxRatio = maxRatio
+		storm.logger = logger
+		if window > 0 {
+			storm.window = window
+		}
+	}
+}
+
+// WithRetryRatioGauge sets gauge to the ratio of retries to requests within the current window, e.g. a gauge named
+// mariadb_operator_http_client_retry_ratio. The gauge is owned, and registered, by the caller.
+func WithRetryRatioGauge(gauge prometheus.Gauge) RetryOption {
+	return func(t *RetryTransport) {
+		t.ensureRetryStorm().gauge = gauge
+	}
+}
+
+func (t *RetryTransport) ensureRetryStorm() *retryStorm {
+	if t.retryStorm == nil {
+		t.retryStorm = &retryStorm{
+			window: defaultRetryStormWindow,
+			logger: logr.Discard(),
+		}
+	}
+	return t.retryStorm
+}
+
+type retryStorm struct {
+	maxRatio float64
+	window   time.Duration
+	logger   logr.Logger
+	gauge    prometheus.Gauge
+
+	mux           sync.Mutex
+	windowStart   time.Time
+	requests      int
+	retries       int
+	disabledUntil time.Time
+}
+
+func (s *retryStorm) recordRequest() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.rollWindow(time.Now())
+	s.requests++
+	s.updateGauge()
+}
+
+// allowRetry records a retry and returns true, unless retries are disabled.
+func (s *retryStorm) allowRetry() bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	now := time.Now()
+	if now.Before(s.disabledUntil) {
+		return false
+	}
+	s.rollWindow(now)
+	if s.maxRatio > 0 && s.requests >= minRetryStormRequests && s.ratio() > s.maxRatio {
+		s.disabledUntil = now.Add(s.window)
+		s.logger.Info("Retry storm detected, disabling retries", "ratio", s.ratio(), "max-ratio", s.maxRatio,
+			"duration", s.window.String())
+		return false
+	}
+	s.retries++
+	s.updateGauge()
+	return true
+}
+
+func (s *retryStorm) rollWindow(now time.Time) {
+	if now.Sub(s.windowStart) < s.window {
+		return
+	}
+	s.windowStart = now
+	s.requests = 0
+	s.retries = 0
+}
+
+func (s *retryStorm) ratio() float64 {
+	if s.requests == 0 {
+		return 0
+	}
+	return float64(s.retries) / float64(s.requests)
+}
+
+func (s *retryStorm) updateGauge() {
+	if s.gauge != nil {
+		s.gauge.Set(s.ratio())
+	}
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// WithClockSkewCorrection sends the current time, as Unix seconds, in timestampHeader for APIs using timestamp based
+// replay protection. When isSkewRejection reports that a response rejected the request because of clock skew, the
+// offset with the server clock is computed from the response Date header and the request is retried once with a
+// corrected timestamp. The offset is kept for subsequent requests. isSkewRejection may read the response body as long
+// as it restores it afterwards. Requests with a body that can't be rewound via GetBody are not retried.
+func WithClockSkewCorrection(timestampHeader string, isSkewRejection func(*http.Response) bool) TransportOption {
+	return func(t *HeadersTransport) {
+		t.clockSkew = &clockSkewCorrection{
+			header:      timestampHeader,
+			isRejection: isSkewRejection,
+		}
+	}
+}
+
+type clockSkewCorrection struct {
+	header      string
+	isRejection func(*http.Response) bool
+	offset      atomic.Int64
+}
+
+func (c *clockSkewCorrection) clone() *clockSkewCorrection {
+	clone := &clockSkewCorrection{
+		header:      c.header,
+		isRejection: c.isRejection,
+	}
+	clone.offset.Store(c.offset.Load())
+	return clone
+}
+
+func (c *clockSkewCorrection) timestamp() string {
+	now := time.Now().Add(time.Duration(c.offset.Load()))
+	return strconv.FormatInt(now.Unix(), 10)
+}
+
+func (c *clockSkewCorrection) roundTrip(rt http.RoundTripper, req *http.Request) (*http.Response, error) {
+	req.Header.Set(c.header, c.timestamp())
+	res, err := rt.RoundTrip(req)
+	if err != nil || !c.isRejection(res) {
+		return res, err
+	}
+	serverTime, err := http.ParseTime(res.Header.Get("Date"))
+	if err != nil {
+		return res, nil
+	}
+	c.offset.Store(int64(time.Until(serverTime)))
+
+	if req.Body != nil && req.GetBody == nil {
+		return res, nil
+	}
+	retryReq := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return res, nil
+		}
+		retryReq.Body = body
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	retryReq.Header.Set(c.header, c.timestamp())
+	return rt.RoundTrip(retryReq)
+}
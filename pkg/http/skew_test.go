@@ -0,0 +1,57 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithClockSkewCorrection(t *testing.T) {
+	serverOffset := time.Hour
+	var (
+		requests int
+		bodies   []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+
+		serverNow := time.Now().Add(serverOffset)
+		w.Header().Set("Date", serverNow.UTC().Format(http.TimeFormat))
+
+		timestamp, err := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+		if err != nil || serverNow.Sub(time.Unix(timestamp, 0)).Abs() > 30*time.Second {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte("clock skew"))
+			return
+		}
+	}))
+	defer server.Close()
+
+	isSkewRejection := func(res *http.Response) bool {
+		return res.StatusCode == http.StatusUnauthorized
+	}
+	client := &http.Client{
+		Transport: NewHeadersTransport(nil, WithClockSkewCorrection("X-Timestamp", isSkewRejection)),
+	}
+
+	res, err := client.Post(server.URL, "text/plain", bytes.NewReader([]byte("payload")))
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, []string{"payload", "payload"}, bodies)
+
+	res, err = client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 3, requests)
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+// WithStatusTransitionLogging logs whenever the status class (2xx, 3xx, 4xx or 5xx) of the responses returned by a host
+// changes, e.g. when a flapping endpoint goes from healthy to unhealthy. Steady responses are not logged.
+func WithStatusTransitionLogging(logger logr.Logger) TransportOption {
+	return func(t *HeadersTransport) {
+		t.statusTransitions = &statusTransitions{
+			logger: logger,
+		}
+	}
+}
+
+type statusTransitions struct {
+	logger  logr.Logger
+	mux     sync.Mutex
+	classes map[string]int
+}
+
+func (s *statusTransitions) clone() *statusTransitions {
+	return &statusTransitions{
+		logger: s.logger,
+	}
+}
+
+func (s *statusTransitions) observe(req *http.Request, res *http.Response) {
+	class := res.StatusCode / 100
+	host := req.URL.Host
+
+	s.mux.Lock()
+	if s.classes == nil {
+		s.classes = make(map[string]int)
+	}
+	previous, ok := s.classes[host]
+	s.classes[host] = class
+	s.mux.Unlock()
+
+	if ok && previous != class {
+		s.logger.Info("Response status class changed", "host", host, "from", statusClass(previous), "to", statusClass(class),
+			"status-code", res.StatusCode)
+	}
+}
+
+func statusClass(class int) string {
+	return fmt.Sprintf("%dxx", class)
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithStatusTransitionLogging(t *testing.T) {
+	var logs []string
+	logger := funcr.New(func(prefix, args string) {
+		logs = append(logs, args)
+	}, funcr.Options{})
+
+	statusCodes := []int{http.StatusOK, http.StatusNoContent, http.StatusBadGateway, http.StatusServiceUnavailable}
+	var calls int
+	rt := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host != "example.com" {
+			return newTestResponse(req, http.StatusInternalServerError, ""), nil
+		}
+		code := statusCodes[calls]
+		calls++
+		return newTestResponse(req, code, ""), nil
+	})
+	transport := NewHeadersTransport(rt, WithStatusTransitionLogging(logger))
+
+	for range statusCodes {
+		res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com", nil))
+		assert.NoError(t, err)
+		assert.NoError(t, res.Body.Close())
+	}
+	res, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://other.example.com", nil))
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+
+	if assert.Len(t, logs, 1) {
+		assert.Contains(t, logs[0], `"from"="2xx"`)
+		assert.Contains(t, logs[0], `"to"="5xx"`)
+		assert.Contains(t, logs[0], fmt.Sprintf(`"status-code"=%d`, http.StatusBadGateway))
+	}
+}
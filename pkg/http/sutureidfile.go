@@ -0,0 +1,87 @@
+package http
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// sutureIDFileCheckInterval is the minimum interval between checks of the Suture ID file for changes.
+const sutureIDFileCheckInterval = time.Second
+
+// WithSutureIDFile reads the Suture ID from a file, e.g. a projected secret, instead of the SUTURE_ID environment
+// variable. The file is checked for changes at most once per second, so rotations are picked up without restarting
+// the process. The file takes precedence over both the context and the environment. If it can't be read at
+// construction time, every RoundTrip fails, whereas later read errors keep the last known ID.
+func WithSutureIDFile(path string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.sutureIDFile = &sutureIDFile{
+			path:     path,
+			interval: sutureIDFileCheckInterval,
+		}
+	}
+}
+
+// sutureIDFile publishes the Suture ID through an atomic pointer, so requests never block on reading it. The request
+// finding the last check older than the interval checks the file, while concurrent requests keep using the current ID.
+type sutureIDFile struct {
+	path     string
+	interval time.Duration
+
+	id        atomic.Pointer[string]
+	lastCheck atomic.Int64
+	checking  atomic.Bool
+
+	// modTime and size are only accessed by the goroutine holding checking.
+	modTime time.Time
+	size    int64
+}
+
+func (f *sutureIDFile) clone() *sutureIDFile {
+	return &sutureIDFile{
+		path:     f.path,
+		interval: f.interval,
+	}
+}
+
+func (f *sutureIDFile) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("error reading Suture ID file: %v", err)
+	}
+	if err := f.read(info); err != nil {
+		return err
+	}
+	f.lastCheck.Store(time.Now().UnixNano())
+	return nil
+}
+
+// get returns the latest Suture ID, reloading the file when it has changed since the last check.
+func (f *sutureIDFile) get() string {
+	now := time.Now()
+	if now.Sub(time.Unix(0, f.lastCheck.Load())) >= f.interval && f.checking.CompareAndSwap(false, true) {
+		if info, err := os.Stat(f.path); err == nil && (!info.ModTime().Equal(f.modTime) || info.Size() != f.size) {
+			_ = f.read(info)
+		}
+		f.lastCheck.Store(now.UnixNano())
+		f.checking.Store(false)
+	}
+	if id := f.id.Load(); id != nil {
+		return *id
+	}
+	return ""
+}
+
+func (f *sutureIDFile) read(info os.FileInfo) error {
+	bytes, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("error reading Suture ID file: %v", err)
+	}
+	id := strings.TrimSpace(string(bytes))
+	f.id.Store(&id)
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return nil
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSutureIDFile(t *testing.T) {
+	t.Setenv("SUTURE_ID", "from-env")
+	path := filepath.Join(t.TempDir(), "suture-id")
+	assert.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0600))
+
+	var gotSutureID string
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotSutureID = req.Header.Get(DefaultSutureIDHeader)
+		return newTestResponse(req, http.StatusOK, ""), nil
+	}), WithSutureIDFile(path))
+	transport.(*HeadersTransport).sutureIDFile.interval = 0
+
+	doRequest := func(ctx context.Context) string {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+		assert.NoError(t, err)
+		_, err = transport.RoundTrip(req)
+		assert.NoError(t, err)
+		return gotSutureID
+	}
+
+	assert.Equal(t, "from-file", doRequest(context.Background()))
+	assert.Equal(t, "from-file", doRequest(WithSutureID(context.Background(), "from-context")))
+
+	assert.NoError(t, os.WriteFile(path, []byte("rotated"), 0600))
+	assert.Equal(t, "rotated", doRequest(context.Background()))
+
+	assert.NoError(t, os.Remove(path))
+	assert.Equal(t, "rotated", doRequest(context.Background()))
+}
+
+func TestWithSutureIDFileMissing(t *testing.T) {
+	sent := false
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sent = true
+		return newTestResponse(req, http.StatusOK, ""), nil
+	}), WithSutureIDFile(filepath.Join(t.TempDir(), "missing")))
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	assert.Error(t, err)
+	assert.False(t, sent)
+}
+
+func TestWithSutureIDFileCheckInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "suture-id")
+	assert.NoError(t, os.WriteFile(path, []byte("from-file"), 0600))
+
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return newTestResponse(req, http.StatusOK, req.Header.Get(DefaultSutureIDHeader)), nil
+	}), WithSutureIDFile(path)).(*HeadersTransport)
+	transport.sutureIDFile.interval = 100 * time.Millisecond
+
+	doRequest := func() string {
+		req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+		assert.NoError(t, err)
+		res, err := transport.RoundTrip(req)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+		return string(body)
+	}
+
+	assert.NoError(t, os.WriteFile(path, []byte("rotated"), 0600))
+	assert.Equal(t, "from-file", doRequest())
+	assert.Eventually(t, func() bool {
+		return doRequest() == "rotated"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "rotated", doRequest())
+		}()
+	}
+	wg.Wait()
+}
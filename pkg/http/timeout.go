@@ -0,0 +1,50 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// TimeoutTransport bounds the duration of every request, so calls to a stalled server can't hang forever.
+// The timeout covers reading the response body: the request context is canceled when the body is closed or when the
+// timeout fires, whichever happens first.
+type TimeoutTransport struct {
+	roundTripper http.RoundTripper
+	timeout      time.Duration
+}
+
+func NewTimeoutTransport(rt http.RoundTripper, timeout time.Duration) http.RoundTripper {
+	transport := &TimeoutTransport{
+		roundTripper: rt,
+		timeout:      timeout,
+	}
+	if transport.roundTripper == nil {
+		transport.roundTripper = http.DefaultTransport
+	}
+	return transport
+}
+
+// WrappedRoundTripper returns the wrapped round tripper, so the transport chain can be inspected.
+func (t *TimeoutTransport) WrappedRoundTripper() http.RoundTripper {
+	return t.roundTripper
+}
+
+func (t *TimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.roundTripper.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	req = req.WithContext(ctx)
+
+	res, err := t.roundTripper.RoundTrip(req)
+	if err != nil {
+		cancel()
+		return nil, wrapDeadlineExceeded(req, err)
+	}
+	res.Body = &cancelOnCloseBody{
+		ReadCloser: res.Body,
+		cancel:     cancel,
+	}
+	return res, nil
+}
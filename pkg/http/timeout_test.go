@@ -0,0 +1,41 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeoutTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{
+		Transport: NewTimeoutTransport(server.Client().Transport, 100*time.Millisecond),
+	}
+
+	_, err := client.Get(server.URL + "/slow")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	res, err := client.Get(server.URL + "/fast")
+	assert.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.NoError(t, res.Body.Close())
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings is the latency breakdown of a request. Phases that did not take place, such as DNS resolution when dialing an
+// IP address or connection establishment when reusing a connection, are zero.
+type Timings struct {
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	// FirstByte is the time elapsed since the request started until the first response byte was received.
+	FirstByte time.Duration
+	// ReusedConn reports whether the request was sent over a previously used connection.
+	ReusedConn bool
+}
+
+// WithTimingBreakdown reports the DNS, connect, TLS handshake and time to first byte timings of every successful
+// request to fn, once the response headers are received. It is composed with any httptrace.ClientTrace already
+// present in the request context.
+func WithTimingBreakdown(fn func(Timings)) TransportOption {
+	return func(t *HeadersTransport) {
+		t.timingsFn = fn
+	}
+}
+
+type timingsRecorder struct {
+	mux          sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	timings      Timings
+}
+
+func newTimingsRecorder() *timingsRecorder {
+	return &timingsRecorder{
+		start: time.Now(),
+	}
+}
+
+func (r *timingsRecorder) withClientTrace(req *http.Request) *http.Request {
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.record(func() { r.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.record(func() { r.timings.DNS = time.Since(r.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			r.record(func() { r.connectStart = time.Now() })
+		},
+		ConnectDone: func(string, string, error) {
+			r.record(func() { r.timings.Connect = time.Since(r.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			r.record(func() { r.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			r.record(func() { r.timings.TLSHandshake = time.Since(r.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.record(func() { r.timings.ReusedConn = info.Reused })
+		},
+		GotFirstResponseByte: func() {
+			r.record(func() { r.timings.FirstByte = time.Since(r.start) })
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+func (r *timingsRecorder) record(fn func()) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	fn()
+}
+
+func (r *timingsRecorder) get() Timings {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.timings
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTimingBreakdown(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	var timings []Timings
+	client := &http.Client{
+		Transport: NewHeadersTransport(server.Client().Transport, WithTimingBreakdown(func(t Timings) {
+			timings = append(timings, t)
+		})),
+	}
+
+	gotFirstByte := false
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		assert.NoError(t, err)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+			GotFirstResponseByte: func() { gotFirstByte = true },
+		}))
+
+		res, err := client.Do(req)
+		assert.NoError(t, err)
+		_, _ = io.Copy(io.Discard, res.Body)
+		assert.NoError(t, res.Body.Close())
+	}
+	assert.True(t, gotFirstByte)
+
+	assert.Len(t, timings, 2)
+	assert.False(t, timings[0].ReusedConn)
+	assert.Greater(t, timings[0].Connect, time.Duration(0))
+	assert.Greater(t, timings[0].TLSHandshake, time.Duration(0))
+	assert.Greater(t, timings[0].FirstByte, time.Duration(0))
+
+	assert.True(t, timings[1].ReusedConn)
+	assert.Zero(t, timings[1].Connect)
+	assert.Zero(t, timings[1].TLSHandshake)
+	assert.Greater(t, timings[1].FirstByte, time.Duration(0))
+}
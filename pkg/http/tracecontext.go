@@ -0,0 +1,24 @@
+package http
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTraceContextPropagation injects the trace context of the request context into the request headers using
+// propagator, e.g. propagation.TraceContext{} to send the W3C traceparent and tracestate headers, so the traces of the
+// outbound calls are stitched together. Requests whose context has no active span are sent as is.
+func WithTraceContextPropagation(propagator propagation.TextMapPropagator) TransportOption {
+	return func(t *HeadersTransport) {
+		t.tracePropagator = propagator
+	}
+}
+
+func (t *HeadersTransport) propagateTraceContext(req *http.Request) {
+	if t.tracePropagator == nil || !trace.SpanContextFromContext(req.Context()).IsValid() {
+		return
+	}
+	t.tracePropagator.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithTraceContextPropagation(t *testing.T) {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	assert.NoError(t, err)
+	spanCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	traceparent := regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		wantTraceparent string
+	}{
+		{
+			name:            "active span",
+			ctx:             spanCtx,
+			wantTraceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		{
+			name: "no active span",
+			ctx:  context.Background(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotTraceparent string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotTraceparent = r.Header.Get("Traceparent")
+			}))
+			defer server.Close()
+
+			client := &http.Client{
+				Transport: NewHeadersTransport(nil, WithTraceContextPropagation(propagation.TraceContext{})),
+			}
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, server.URL, nil)
+			assert.NoError(t, err)
+			res, err := client.Do(req)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+
+			assert.Equal(t, tt.wantTraceparent, gotTraceparent)
+			if tt.wantTraceparent != "" {
+				assert.Regexp(t, traceparent, gotTraceparent)
+			}
+		})
+	}
+}
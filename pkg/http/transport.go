@@ -1,42 +1,537 @@
 package http
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
 	"net/http"
 	"os"
+	"slices"
+	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/propagation"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/client-go/rest"
 )
 
+// DefaultSutureIDHeader is the header carrying the Suture ID unless overridden via WithSutureIDHeader.
+const DefaultSutureIDHeader = "Suture_ID"
+
+const sutureIDEnv = "SUTURE_ID"
+
+// TransportOption configures a HeadersTransport.
+type TransportOption func(*HeadersTransport)
+
 type HeadersTransport struct {
-	roundTripper http.RoundTripper
-	headers      map[string]string
+	roundTripper   http.RoundTripper
+	headers        map[string]string
+	multiHeaders   http.Header
+	userAgent      string
+	headerFunc     func(*http.Request) (map[string]string, error)
+	sutureIDHeader string
+	// sutureID is the Suture ID snapshotted at construction time, used unless dynamicSutureID is set.
+	sutureID        string
+	dynamicSutureID bool
+	sutureIDFile    *sutureIDFile
+	// generatedSutureID generates a Suture ID for the requests that have none.
+	generatedSutureID bool
+	// configErr holds any invalid configuration detected at construction time, see Validate. It is returned by every
+	// RoundTrip.
+	configErr error
+
+	// headerSource is shared with clones, as it may reflect a single watched ConfigMap.
+	headerSource HeaderSource
+
+	mergePolicies map[string]MergePolicy
+	excludePaths  []string
+
+	templateKeys           []string
+	templateKeepUnresolved bool
+
+	methodSemaphores map[string]chan struct{}
+	hostSemaphores   *hostSemaphores
+
+	throttleSoftLimit int
+	throttleStep      time.Duration
+	inFlightThrottle  inFlightThrottle
+
+	sessionAffinity   bool
+	sessionLimit      int
+	sessionTransports sessionTransports
+
+	httpsOnly           bool
+	plaintextAllowHosts []string
+	requestIDEchoHeader string
+	requestFilter       func(*http.Request) bool
+	onDeny              func(*http.Request) (*http.Response, error)
+	bodyPrefixSize      int
+	bodyPrefixValidate  func([]byte) error
+
+	forwardHeaderAllowlist []string
+
+	trailerSink  func(map[string]string)
+	trailerNames []string
+
+	stripResponseHeaders []string
+	responseLatency      bool
+	statusRemap          func(*http.Response) int
+	responseReadTimeout  time.Duration
+	bodyProgressInterval time.Duration
+	bodyProgressFn       func(int64)
+
+	validateContentLength    bool
+	gzipResponseMinBytes     int
+	normalizeResponseHeaders bool
+
+	requestCompression         bool
+	requestCompressionMinBytes int
+
+	responseIDHeader string
+	onResponse       func(reqSutureID, respID string, status int)
+
+	timingsFn func(Timings)
+	clockSkew *clockSkewCorrection
+
+	connPool    *connPoolMetrics
+	connPoolErr error
+
+	baggagePropagation bool
+	tracePropagator    propagation.TextMapPropagator
+	errorAggregator    *errorAggregator
+	statusTransitions  *statusTransitions
+	requestLogger      *logr.Logger
 }
 
-func NewHeadersTransport(rt http.RoundTripper, headers map[string]string) http.RoundTripper {
+// NewHeadersTransport creates a HeadersTransport wrapping rt, http.DefaultTransport if nil.
+// Options are applied in order, so later options override earlier ones configuring the same setting.
+func NewHeadersTransport(rt http.RoundTripper, opts ...TransportOption) http.RoundTripper {
 	transport := &HeadersTransport{
-		roundTripper: rt,
-		headers:      headers,
+		roundTripper:   rt,
+		sutureIDHeader: DefaultSutureIDHeader,
+		sutureID:       os.Getenv(sutureIDEnv),
 	}
 	if transport.roundTripper == nil {
 		transport.roundTripper = http.DefaultTransport
 	}
+	for _, setOpt := range opts {
+		setOpt(transport)
+	}
+	transport.configErr = transport.validate()
 	return transport
 }
 
+// NewHeadersTransportE is like NewHeadersTransport, but it returns an error when the configuration is invalid, e.g.
+// because of an invalid header name, instead of failing every request.
+func NewHeadersTransportE(rt http.RoundTripper, opts ...TransportOption) (*HeadersTransport, error) {
+	transport := NewHeadersTransport(rt, opts...).(*HeadersTransport)
+	if err := transport.Validate(); err != nil {
+		return nil, err
+	}
+	return transport, nil
+}
+
+// Validate returns the configuration error detected at construction time, if any.
+func (t *HeadersTransport) Validate() error {
+	if t.configErr != nil {
+		return fmt.Errorf("invalid transport configuration: %v", t.configErr)
+	}
+	return nil
+}
+
+// WithHeaders sets the static headers sent with every request, replacing any previously configured ones.
+func WithHeaders(headers map[string]string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.headers = maps.Clone(headers)
+	}
+}
+
+// WithStaticSutureID sets the Suture ID sent with every request, instead of the SUTURE_ID environment variable value
+// read at construction time.
+func WithStaticSutureID(id string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.sutureID = id
+		t.dynamicSutureID = false
+	}
+}
+
+// WithDynamicSutureID reads the SUTURE_ID environment variable on every request rather than once at construction time.
+func WithDynamicSutureID() TransportOption {
+	return func(t *HeadersTransport) {
+		t.dynamicSutureID = true
+	}
+}
+
+// WithGeneratedFallback generates a random UUID as Suture ID for the requests that have none, neither from the
+// environment nor from the context. The generated ID is set in the context of the request, so callers can retrieve it
+// from the response via SutureIDFromContext(res.Request.Context()).
+func WithGeneratedFallback() TransportOption {
+	return func(t *HeadersTransport) {
+		t.generatedSutureID = true
+	}
+}
+
+// WithSutureIDHeader sets the name of the header carrying the Suture ID, DefaultSutureIDHeader by default.
+func WithSutureIDHeader(name string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.sutureIDHeader = name
+	}
+}
+
+// Clone returns a copy of the transport with opts applied on top of its configuration.
+// The configuration is deep-copied: the clone gets its own headers, concurrency limits and session pools, so it does
+// not share in-flight accounting with the original. The base round tripper, and therefore its connection pool, is shared.
+func (t *HeadersTransport) Clone(opts ...TransportOption) *HeadersTransport {
+	clone := &HeadersTransport{
+		roundTripper:        t.roundTripper,
+		headers:             maps.Clone(t.headers),
+		multiHeaders:        t.multiHeaders.Clone(),
+		userAgent:           t.userAgent,
+		headerFunc:          t.headerFunc,
+		headerSource:        t.headerSource,
+		sutureIDHeader:      t.sutureIDHeader,
+		sutureID:            t.sutureID,
+		dynamicSutureID:     t.dynamicSutureID,
+		generatedSutureID:   t.generatedSutureID,
+		mergePolicies:       maps.Clone(t.mergePolicies),
+		excludePaths:        slices.Clone(t.excludePaths),
+		throttleSoftLimit:   t.throttleSoftLimit,
+		throttleStep:        t.throttleStep,
+		sessionAffinity:     t.sessionAffinity,
+		sessionLimit:        t.sessionLimit,
+		httpsOnly:           t.httpsOnly,
+		plaintextAllowHosts: slices.Clone(t.plaintextAllowHosts),
+		requestIDEchoHeader: t.requestIDEchoHeader,
+		requestFilter:       t.requestFilter,
+		onDeny:              t.onDeny,
+		bodyPrefixSize:      t.bodyPrefixSize,
+		bodyPrefixValidate:  t.bodyPrefixValidate,
+		trailerSink:         t.trailerSink,
+		trailerNames:        slices.Clone(t.trailerNames),
+
+		stripResponseHeaders: slices.Clone(t.stripResponseHeaders),
+		responseLatency:      t.responseLatency,
+		statusRemap:          t.statusRemap,
+		responseReadTimeout:  t.responseReadTimeout,
+		bodyProgressInterval: t.bodyProgressInterval,
+		bodyProgressFn:       t.bodyProgressFn,
+
+		validateContentLength:    t.validateContentLength,
+		gzipResponseMinBytes:     t.gzipResponseMinBytes,
+		normalizeResponseHeaders: t.normalizeResponseHeaders,
+
+		requestCompression:         t.requestCompression,
+		requestCompressionMinBytes: t.requestCompressionMinBytes,
+
+		responseIDHeader: t.responseIDHeader,
+		onResponse:       t.onResponse,
+
+		timingsFn: t.timingsFn,
+
+		connPool:    t.connPool,
+		connPoolErr: t.connPoolErr,
+
+		baggagePropagation: t.baggagePropagation,
+		tracePropagator:    t.tracePropagator,
+		requestLogger:      t.requestLogger,
+
+		forwardHeaderAllowlist: slices.Clone(t.forwardHeaderAllowlist),
+		templateKeys:           slices.Clone(t.templateKeys),
+		templateKeepUnresolved: t.templateKeepUnresolved,
+	}
+	if t.clockSkew != nil {
+		clone.clockSkew = t.clockSkew.clone()
+	}
+	if t.errorAggregator != nil {
+		clone.errorAggregator = t.errorAggregator.clone()
+	}
+	if t.sutureIDFile != nil {
+		clone.sutureIDFile = t.sutureIDFile.clone()
+	}
+	if t.statusTransitions != nil {
+		clone.statusTransitions = t.statusTransitions.clone()
+	}
+	if t.methodSemaphores != nil {
+		clone.methodSemaphores = make(map[string]chan struct{}, len(t.methodSemaphores))
+		for method, sem := range t.methodSemaphores {
+			clone.methodSemaphores[method] = make(chan struct{}, cap(sem))
+		}
+	}
+	if t.hostSemaphores != nil {
+		clone.hostSemaphores = &hostSemaphores{
+			limit: t.hostSemaphores.limit,
+		}
+	}
+	for _, setOpt := range opts {
+		setOpt(clone)
+	}
+	clone.configErr = clone.validate()
+	return clone
+}
+
 func (t *HeadersTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timeout, hasTimeout := timeoutFromContext(req.Context())
+	if !hasTimeout && t.responseReadTimeout == 0 {
+		return t.roundTrip(req)
+	}
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if hasTimeout {
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+	res, err := t.roundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if t.responseReadTimeout > 0 {
+		res.Body = newReadTimeoutBody(res.Body, t.responseReadTimeout, cancel)
+	} else {
+		res.Body = &cancelOnCloseBody{
+			ReadCloser: res.Body,
+			cancel:     cancel,
+		}
+	}
+	return res, nil
+}
+
+// WrappedRoundTripper returns the base round tripper, so the transport chain can be inspected.
+func (t *HeadersTransport) WrappedRoundTripper() http.RoundTripper {
+	return t.roundTripper
+}
+
+func (t *HeadersTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	if err := t.checkScheme(req); err != nil {
+		return nil, err
+	}
+	if allowed, res, err := t.filterRequest(req); !allowed {
+		return res, err
+	}
+	if t.isExcludedPath(req) {
+		return t.roundTripper.RoundTrip(req)
+	}
+	// RoundTrippers must not modify the request, headers are set on a copy
+	req = req.Clone(req.Context())
+	if err := t.validateBodyPrefix(req); err != nil {
+		return nil, err
+	}
+	release, releaseOnClose, err := t.acquireSemaphores(req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	// releaseOnClose is handed over to the response body once the response is received
+	defer func() {
+		if releaseOnClose != nil {
+			releaseOnClose()
+		}
+	}()
+
+	req, err = t.setRequestHeaders(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.compressRequest(req); err != nil {
+		return nil, fmt.Errorf("error compressing request body: %v", err)
+	}
+	if err := injectFault(req); err != nil {
+		return nil, err
+	}
+	if t.connPool != nil {
+		var releaseConn func()
+		req, releaseConn = t.connPool.withClientTrace(req)
+		releaseHost := releaseOnClose
+		releaseOnClose = func() {
+			releaseConn()
+			releaseHost()
+		}
+	}
+	var timings *timingsRecorder
+	if t.timingsFn != nil {
+		timings = newTimingsRecorder()
+		req = timings.withClientTrace(req)
+	}
+	start := time.Now()
+	res, err := t.send(req)
+	t.logRequest(req, res, err, time.Since(start))
+	if err != nil {
+		if t.errorAggregator != nil {
+			t.errorAggregator.record(req, err)
+		}
+		return nil, wrapDeadlineExceeded(req, err)
+	}
+	res.Body = &cancelOnCloseBody{
+		ReadCloser: res.Body,
+		cancel:     releaseOnClose,
+	}
+	releaseOnClose = nil
+	if timings != nil {
+		t.timingsFn(timings.get())
+	}
+	return t.finishResponse(req, res, start)
+}
+
+// setRequestHeaders sets the configured, inherited and context headers, along with the Suture ID, on req. It returns
+// req with an updated context when a Suture ID is generated.
+func (t *HeadersTransport) setRequestHeaders(req *http.Request) (*http.Request, error) {
 	for k, v := range t.headers {
+		t.mergeTemplatedHeader(req, k, v)
+	}
+	for k, values := range t.multiHeaders {
+		t.mergeHeaderValues(req.Header, k, values)
+	}
+	if t.headerSource != nil {
+		for k, v := range t.headerSource.Headers() {
+			t.mergeTemplatedHeader(req, k, v)
+		}
+	}
+	if err := t.setHeaderFuncHeaders(req); err != nil {
+		return nil, err
+	}
+	t.setUserAgent(req)
+	for k, v := range inheritedHeadersFromContext(req.Context()) {
+		if !t.isForwardAllowed(k) {
+			continue
+		}
+		req.Header[k] = slices.Clone(v)
+	}
+	for k, v := range headersFromContext(req.Context()) {
 		req.Header.Set(k, v)
 	}
-	req.Header.Set("Suture_ID", os.Getenv("SUTURE_ID"))
+	t.propagateBaggage(req)
+	t.propagateTraceContext(req)
+	sutureID := t.resolveSutureID(req)
+	if sutureID == "" && t.generatedSutureID {
+		sutureID = uuid.NewString()
+		req = req.WithContext(WithSutureID(req.Context(), sutureID))
+	}
+	if sutureID != "" {
+		req.Header.Set(t.sutureIDHeader, sutureID)
+	}
 	if req.Body != nil {
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if req.Header.Get("Accept") == "" {
+			req.Header.Set("Accept", "application/json")
+		}
+	}
+	return req, nil
+}
+
+// finishResponse runs the response hooks, closing the response body when one of them fails.
+func (t *HeadersTransport) finishResponse(req *http.Request, res *http.Response, start time.Time) (*http.Response, error) {
+	t.notifyResponse(req, res)
+	if err := t.verifyRequestIDEcho(req, res); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if t.statusTransitions != nil {
+		t.statusTransitions.observe(req, res)
+	}
+	if t.responseLatency {
+		setResponseLatency(res, time.Since(start))
+	}
+	if err := t.processResponse(res); err != nil {
+		res.Body.Close()
+		return nil, fmt.Errorf("error processing response: %v", err)
+	}
+	return res, nil
+}
+
+// wrapDeadlineExceeded ensures that errors caused by the request context deadline, which some round trippers report as
+// a generic cancellation, match context.DeadlineExceeded. Errors not caused by the client-side deadline are returned as is.
+func wrapDeadlineExceeded(req *http.Request, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || !errors.Is(req.Context().Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", context.DeadlineExceeded, err)
+}
+
+func (t *HeadersTransport) send(req *http.Request) (*http.Response, error) {
+	rt := t.sessionRoundTripper(req)
+	if t.clockSkew != nil {
+		return t.clockSkew.roundTrip(rt, req)
+	}
+	return rt.RoundTrip(req)
+}
+
+// resolveSutureID returns the Suture ID of a request. The precedence is: Suture ID file, context, environment.
+func (t *HeadersTransport) resolveSutureID(req *http.Request) string {
+	if t.sutureIDFile != nil {
+		if id := t.sutureIDFile.get(); id != "" {
+			return id
+		}
 	}
-	return t.roundTripper.RoundTrip(req)
+	if id, ok := SutureIDFromContext(req.Context()); ok {
+		return id
+	}
+	if t.dynamicSutureID {
+		return os.Getenv(sutureIDEnv)
+	}
+	return t.sutureID
 }
 
-// WrapRestConfigWithSutureID wraps a Kubernetes rest.Config to add the Suture_ID header to all requests
+func (t *HeadersTransport) validate() error {
+	if t.connPoolErr != nil {
+		return fmt.Errorf("error registering connection pool metrics: %v", t.connPoolErr)
+	}
+	if err := ValidateHeaderName(t.sutureIDHeader); err != nil {
+		return err
+	}
+	if t.sutureIDFile != nil {
+		// Loading the file upfront makes a missing or unreadable file a configuration error.
+		if err := t.sutureIDFile.load(); err != nil {
+			return err
+		}
+	}
+	if err := validateMultiHeaders(t.multiHeaders); err != nil {
+		return err
+	}
+	return validateHeaders(t.headers)
+}
+
+// WrapRestConfigWithSutureID wraps a Kubernetes rest.Config to add the Suture_ID header to all requests.
+// It is idempotent, as the other WrapRestConfig functions: when the transport chain already contains a HeadersTransport,
+// e.g. because the config was wrapped before, the options are merged into that one and no other HeadersTransport is added.
 func WrapRestConfigWithSutureID(config *rest.Config) {
+	WrapRestConfigWithHeaders(config, map[string]string{})
+}
+
+// WrapRestConfigWithSutureIDHeader wraps a Kubernetes rest.Config to add the Suture ID to all requests, using the given header name
+func WrapRestConfigWithSutureIDHeader(config *rest.Config, header string) {
+	wrapRestConfig(config, WithSutureIDHeader(header))
+}
+
+// WrapRestConfigWithHeaders wraps a Kubernetes rest.Config to add the Suture ID, along with the given headers
+// (e.g. X-Operator-Version), to all requests
+func WrapRestConfigWithHeaders(config *rest.Config, headers map[string]string) {
+	wrapRestConfig(config, withMergedHeaders(headers))
+}
+
+// WrapRestConfigWithUserAgent wraps a Kubernetes rest.Config to add the Suture ID to all requests, and sets its
+// User-Agent to the one returned by UserAgent unless one is already configured
+func WrapRestConfigWithUserAgent(config *rest.Config, product, version string) {
+	if config == nil {
+		return
+	}
+	// client-go sets a default User-Agent on every request, so it must be configured at the rest.Config level
+	if config.UserAgent == "" {
+		config.UserAgent = UserAgent(product, version)
+	}
+	wrapRestConfig(config, WithUserAgent(product, version))
+}
+
+func wrapRestConfig(config *rest.Config, opts ...TransportOption) {
 	if config == nil {
 		return
 	}
@@ -48,7 +543,43 @@ func WrapRestConfigWithSutureID(config *rest.Config) {
 		if originalWrap != nil {
 			rt = originalWrap(rt)
 		}
+		// Merge the options into the Suture_ID transport if one has already been installed
+		if headersTransport, ok := rt.(*HeadersTransport); ok {
+			return headersTransport.Clone(opts...)
+		}
+		if headersTransport := findHeadersTransport(rt); headersTransport != nil {
+			// The chain has just been built by the previous WrapTransport, so the transport is not in use yet
+			for _, setOpt := range opts {
+				setOpt(headersTransport)
+			}
+			headersTransport.configErr = headersTransport.validate()
+			return rt
+		}
 		// Then wrap with our Suture_ID transport
-		return NewHeadersTransport(rt, map[string]string{})
+		return NewHeadersTransport(rt, opts...)
+	}
+}
+
+func findHeadersTransport(rt http.RoundTripper) *HeadersTransport {
+	for rt != nil {
+		if headersTransport, ok := rt.(*HeadersTransport); ok {
+			return headersTransport
+		}
+		wrapper, ok := rt.(utilnet.RoundTripperWrapper)
+		if !ok {
+			return nil
+		}
+		rt = wrapper.WrappedRoundTripper()
+	}
+	return nil
+}
+
+// withMergedHeaders adds static headers to the ones already configured, overriding the ones with the same name.
+func withMergedHeaders(headers map[string]string) TransportOption {
+	return func(t *HeadersTransport) {
+		if t.headers == nil {
+			t.headers = make(map[string]string, len(headers))
+		}
+		maps.Copy(t.headers, headers)
 	}
 }
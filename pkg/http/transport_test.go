@@ -0,0 +1,543 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newTestResponse(req *http.Request, statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestHeadersTransportClone(t *testing.T) {
+	var gotHeader string
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotHeader = req.Header.Get("X-Operator")
+		return newTestResponse(req, http.StatusOK, ""), nil
+	})
+	headers := map[string]string{"X-Operator": "mariadb-operator"}
+	original := NewHeadersTransport(base, WithHeaders(headers), WithMethodConcurrencyLimit(map[string]int{
+		http.MethodGet: 1,
+	})).(*HeadersTransport)
+
+	clone := original.Clone(WithHTTPSOnly())
+	headers["X-Operator"] = "mutated"
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+	_, err = clone.RoundTrip(req)
+	assert.Error(t, err)
+
+	req, err = http.NewRequest(http.MethodGet, "https://example.com", nil)
+	assert.NoError(t, err)
+	_, err = clone.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "mariadb-operator", gotHeader)
+
+	assert.Equal(t, 1, cap(clone.methodSemaphores[http.MethodGet]))
+	assert.NotEqual(t, original.methodSemaphores[http.MethodGet], clone.methodSemaphores[http.MethodGet])
+}
+
+func TestHeadersTransport_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name         string
+		sutureID     string
+		opts         []TransportOption
+		wantHeader   string
+		wantSutureID string
+	}{
+		{
+			name:         "default header",
+			sutureID:     "suture-123",
+			wantHeader:   DefaultSutureIDHeader,
+			wantSutureID: "suture-123",
+		},
+		{
+			name:         "custom header",
+			sutureID:     "suture-123",
+			opts:         []TransportOption{WithSutureIDHeader("X-Suture-Id")},
+			wantHeader:   "X-Suture-Id",
+			wantSutureID: "suture-123",
+		},
+		{
+			name:         "without SUTURE_ID set",
+			sutureID:     "",
+			wantHeader:   DefaultSutureIDHeader,
+			wantSutureID: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SUTURE_ID", tt.sutureID)
+
+			var gotHeaders http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotHeaders = r.Header.Clone()
+			}))
+			defer server.Close()
+
+			client := &http.Client{
+				Transport: NewHeadersTransport(nil, tt.opts...),
+			}
+			res, err := client.Get(server.URL)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+
+			if tt.wantSutureID == "" {
+				assert.NotContains(t, gotHeaders, http.CanonicalHeaderKey(tt.wantHeader))
+			} else {
+				assert.Equal(t, tt.wantSutureID, gotHeaders.Get(tt.wantHeader))
+			}
+		})
+	}
+}
+
+func TestWrapRestConfigWithSutureIDHeader(t *testing.T) {
+	t.Setenv("SUTURE_ID", "suture-123")
+
+	var gotHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		wrap       func(*rest.Config)
+		wantHeader string
+	}{
+		{
+			name:       "default header",
+			wrap:       WrapRestConfigWithSutureID,
+			wantHeader: DefaultSutureIDHeader,
+		},
+		{
+			name: "custom header",
+			wrap: func(config *rest.Config) {
+				WrapRestConfigWithSutureIDHeader(config, "X-Suture-Id")
+			},
+			wantHeader: "X-Suture-Id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &rest.Config{}
+			tt.wrap(config)
+			client := &http.Client{
+				Transport: config.WrapTransport(http.DefaultTransport),
+			}
+			res, err := client.Get(server.URL)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+
+			assert.Equal(t, "suture-123", gotHeaders.Get(tt.wantHeader))
+		})
+	}
+}
+
+func TestWrapRestConfigWithHeaders(t *testing.T) {
+	t.Setenv("SUTURE_ID", "suture-123")
+
+	var gotHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	config := &rest.Config{}
+	WrapRestConfigWithHeaders(config, map[string]string{
+		"X-Operator-Version": "25.10.0",
+	})
+	client := &http.Client{
+		Transport: config.WrapTransport(http.DefaultTransport),
+	}
+	res, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.NoError(t, res.Body.Close())
+
+	assert.Equal(t, "25.10.0", gotHeaders.Get("X-Operator-Version"))
+	assert.Equal(t, "suture-123", gotHeaders.Get(DefaultSutureIDHeader))
+}
+
+func TestWrapRestConfigWithSutureIDIdempotent(t *testing.T) {
+	t.Setenv("SUTURE_ID", "suture-123")
+
+	config := &rest.Config{}
+	WrapRestConfigWithSutureID(config)
+	WrapRestConfigWithSutureID(config)
+
+	rt := config.WrapTransport(http.DefaultTransport)
+	var headersTransports int
+	for rt != nil {
+		if _, ok := rt.(*HeadersTransport); ok {
+			headersTransports++
+		}
+		wrapper, ok := rt.(utilnet.RoundTripperWrapper)
+		if !ok {
+			break
+		}
+		rt = wrapper.WrappedRoundTripper()
+	}
+	assert.Equal(t, 1, headersTransports)
+}
+
+func TestWrapRestConfigMergesOptions(t *testing.T) {
+	t.Setenv("SUTURE_ID", "suture-123")
+
+	var gotHeaders http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		wrapper func(http.RoundTripper) http.RoundTripper
+	}{
+		{
+			name: "outermost",
+		},
+		{
+			name: "behind retry transport",
+			wrapper: func(rt http.RoundTripper) http.RoundTripper {
+				return NewRetryTransport(rt)
+			},
+		},
+		{
+			name: "behind timeout and rate limit transports",
+			wrapper: func(rt http.RoundTripper) http.RoundTripper {
+				return NewRateLimitTransport(NewTimeoutTransport(rt, time.Minute), rate.Inf, 1)
+			},
+		},
+		{
+			name: "behind circuit breaker transport",
+			wrapper: func(rt http.RoundTripper) http.RoundTripper {
+				return NewCircuitBreakerTransport(rt)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &rest.Config{}
+			WrapRestConfigWithSutureID(config)
+			if tt.wrapper != nil {
+				config.Wrap(tt.wrapper)
+			}
+			WrapRestConfigWithHeaders(config, map[string]string{
+				"X-Operator-Version": "25.10.0",
+			})
+			WrapRestConfigWithUserAgent(config, "mariadb-operator", "1.2.3")
+
+			rt := config.WrapTransport(http.DefaultTransport)
+			var headersTransports int
+			for rt != nil {
+				if _, ok := rt.(*HeadersTransport); ok {
+					headersTransports++
+				}
+				wrapper, ok := rt.(utilnet.RoundTripperWrapper)
+				if !ok {
+					break
+				}
+				rt = wrapper.WrappedRoundTripper()
+			}
+			assert.Equal(t, 1, headersTransports)
+
+			client := &http.Client{
+				Transport: config.WrapTransport(http.DefaultTransport),
+			}
+			res, err := client.Get(server.URL)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+
+			assert.Equal(t, "suture-123", gotHeaders.Get(DefaultSutureIDHeader))
+			assert.Equal(t, "25.10.0", gotHeaders.Get("X-Operator-Version"))
+			assert.Equal(t, UserAgent("mariadb-operator", "1.2.3"), gotHeaders.Get("User-Agent"))
+		})
+	}
+}
+
+func TestHeadersTransportDoesNotMutateRequest(t *testing.T) {
+	t.Setenv("SUTURE_ID", "suture-123")
+
+	var gotHeaders http.Header
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotHeaders = req.Header.Clone()
+		return newTestResponse(req, http.StatusOK, ""), nil
+	}), WithHeaders(map[string]string{
+		"X-Operator": "mariadb-operator",
+	}))
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com", strings.NewReader(`{"foo":"bar"}`))
+	assert.NoError(t, err)
+	req.Header.Set("X-Tenant", "tenant-a")
+	original := req.Header.Clone()
+
+	for i := 0; i < 2; i++ {
+		_, err = transport.RoundTrip(req)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, original, req.Header)
+	assert.Equal(t, []string{"mariadb-operator"}, gotHeaders.Values("X-Operator"))
+	assert.Equal(t, "suture-123", gotHeaders.Get(DefaultSutureIDHeader))
+	assert.Equal(t, "tenant-a", gotHeaders.Get("X-Tenant"))
+}
+
+func TestHeadersTransportDeadlineExceeded(t *testing.T) {
+	errCanceled := errors.New("net/http: request canceled")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		base         http.RoundTripper
+		path         string
+		wantDeadline bool
+		wantErr      bool
+	}{
+		{
+			name:         "client deadline",
+			base:         http.DefaultTransport,
+			path:         "/slow",
+			wantDeadline: true,
+			wantErr:      true,
+		},
+		{
+			name: "client deadline reported as cancellation",
+			base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				<-req.Context().Done()
+				return nil, errCanceled
+			}),
+			path:         "/slow",
+			wantDeadline: true,
+			wantErr:      true,
+		},
+		{
+			name: "other error",
+			base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return nil, errCanceled
+			}),
+			path:         "/",
+			wantDeadline: false,
+			wantErr:      true,
+		},
+		{
+			name:         "server gateway timeout",
+			base:         http.DefaultTransport,
+			path:         "/",
+			wantDeadline: false,
+			wantErr:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+tt.path, nil)
+			assert.NoError(t, err)
+
+			res, err := NewHeadersTransport(tt.base).RoundTrip(req)
+			assert.Equal(t, tt.wantDeadline, errors.Is(err, context.DeadlineExceeded))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+			assert.Equal(t, http.StatusGatewayTimeout, res.StatusCode)
+		})
+	}
+}
+
+func TestHeadersTransportContentHeaders(t *testing.T) {
+	tests := []struct {
+		name            string
+		headers         map[string]string
+		wantContentType string
+		wantAccept      string
+	}{
+		{
+			name:            "JSON defaults",
+			wantContentType: "application/json",
+			wantAccept:      "application/json",
+		},
+		{
+			name: "caller-supplied",
+			headers: map[string]string{
+				"Content-Type": "application/gzip",
+				"Accept":       "*/*",
+			},
+			wantContentType: "application/gzip",
+			wantAccept:      "*/*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHeaders http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotHeaders = r.Header.Clone()
+			}))
+			defer server.Close()
+
+			req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+			assert.NoError(t, err)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			client := &http.Client{
+				Transport: NewHeadersTransport(nil),
+			}
+			res, err := client.Do(req)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+
+			assert.Equal(t, tt.wantContentType, gotHeaders.Get("Content-Type"))
+			assert.Equal(t, tt.wantAccept, gotHeaders.Get("Accept"))
+		})
+	}
+}
+
+func TestHeadersTransportSutureIDSnapshot(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []TransportOption
+		wantSutureID string
+	}{
+		{
+			name:         "snapshot at construction",
+			wantSutureID: "initial",
+		},
+		{
+			name:         "static",
+			opts:         []TransportOption{WithStaticSutureID("static")},
+			wantSutureID: "static",
+		},
+		{
+			name:         "dynamic",
+			opts:         []TransportOption{WithDynamicSutureID()},
+			wantSutureID: "updated",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SUTURE_ID", "initial")
+
+			var gotSutureID string
+			transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				gotSutureID = req.Header.Get(DefaultSutureIDHeader)
+				return newTestResponse(req, http.StatusOK, ""), nil
+			}), tt.opts...)
+
+			t.Setenv("SUTURE_ID", "updated")
+
+			req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			assert.NoError(t, err)
+			_, err = transport.RoundTrip(req)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.wantSutureID, gotSutureID)
+		})
+	}
+}
+
+func BenchmarkHeadersTransportSutureID(b *testing.B) {
+	b.Setenv("SUTURE_ID", "suture-123")
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+	}
+	base := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return res, nil
+	})
+	benchmarks := []struct {
+		name string
+		opts []TransportOption
+	}{
+		{
+			name: "static",
+		},
+		{
+			name: "dynamic",
+			opts: []TransportOption{WithDynamicSutureID()},
+		},
+	}
+
+	for _, bb := range benchmarks {
+		b.Run(bb.name, func(b *testing.B) {
+			transport := NewHeadersTransport(base, bb.opts...)
+			req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, _ = transport.RoundTrip(req)
+			}
+		})
+	}
+}
+
+func TestNewHeadersTransportOptionsOrder(t *testing.T) {
+	var gotHeaders http.Header
+	transport := NewHeadersTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		gotHeaders = req.Header.Clone()
+		return newTestResponse(req, http.StatusOK, ""), nil
+	}),
+		WithHeaders(map[string]string{"X-Operator": "first"}),
+		WithSutureIDHeader("X-First-Suture-Id"),
+		WithStaticSutureID("first"),
+		WithHeaders(map[string]string{"X-Tenant": "second"}),
+		WithSutureIDHeader("X-Second-Suture-Id"),
+		WithStaticSutureID("second"),
+	)
+
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	assert.NoError(t, err)
+	_, err = transport.RoundTrip(req)
+	assert.NoError(t, err)
+
+	assert.Empty(t, gotHeaders.Get("X-Operator"))
+	assert.Equal(t, "second", gotHeaders.Get("X-Tenant"))
+	assert.Empty(t, gotHeaders.Get("X-First-Suture-Id"))
+	assert.Equal(t, "second", gotHeaders.Get("X-Second-Suture-Id"))
+}
@@ -0,0 +1,26 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// UserAgent returns a User-Agent identifying a product and its version, along with the platform it runs on,
+// e.g. mariadb-operator/1.2.3 (linux/amd64).
+func UserAgent(product, version string) string {
+	return fmt.Sprintf("%s/%s (%s/%s)", product, version, runtime.GOOS, runtime.GOARCH)
+}
+
+// WithUserAgent sets the User-Agent returned by UserAgent on the requests that don't carry one already.
+func WithUserAgent(product, version string) TransportOption {
+	return func(t *HeadersTransport) {
+		t.userAgent = UserAgent(product, version)
+	}
+}
+
+func (t *HeadersTransport) setUserAgent(req *http.Request) {
+	if t.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestWithUserAgent(t *testing.T) {
+	wantUserAgent := fmt.Sprintf("mariadb-operator/1.2.3 (%s/%s)", runtime.GOOS, runtime.GOARCH)
+
+	tests := []struct {
+		name          string
+		userAgent     string
+		wantUserAgent string
+	}{
+		{
+			name:          "default",
+			wantUserAgent: wantUserAgent,
+		},
+		{
+			name:          "explicit",
+			userAgent:     "custom/1.0",
+			wantUserAgent: "custom/1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotUserAgent string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUserAgent = r.UserAgent()
+			}))
+			defer server.Close()
+
+			client := &http.Client{
+				Transport: NewHeadersTransport(nil, WithUserAgent("mariadb-operator", "1.2.3")),
+			}
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			assert.NoError(t, err)
+			if tt.userAgent != "" {
+				req.Header.Set("User-Agent", tt.userAgent)
+			}
+			res, err := client.Do(req)
+			assert.NoError(t, err)
+			assert.NoError(t, res.Body.Close())
+
+			assert.Equal(t, tt.wantUserAgent, gotUserAgent)
+		})
+	}
+}
+
+func TestWrapRestConfigWithUserAgent(t *testing.T) {
+	var gotUserAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserAgent = r.UserAgent()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"ConfigMapList","items":[]}`))
+	}))
+	defer server.Close()
+
+	config := &rest.Config{Host: server.URL}
+	WrapRestConfigWithUserAgent(config, "mariadb-operator", "1.2.3")
+	clientset, err := kubernetes.NewForConfig(config)
+	assert.NoError(t, err)
+
+	_, err = clientset.CoreV1().ConfigMaps(corev1.NamespaceDefault).List(context.Background(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, UserAgent("mariadb-operator", "1.2.3"), gotUserAgent)
+}